@@ -24,7 +24,7 @@ func main() {
 	app := setupApp()
 
 	if err := app.Run(os.Args); err != nil {
-		log.Fatal().Err(err)
+		log.Fatal().Err(err).Send()
 	}
 }
 
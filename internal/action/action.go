@@ -1,10 +1,18 @@
 package action
 
 import (
+	"fmt"
+	"path/filepath"
+
 	"github.com/WIttyJudge/adless/internal/action/exit"
 	"github.com/WIttyJudge/adless/internal/config"
+	"github.com/WIttyJudge/adless/internal/hostsfile"
+	"github.com/WIttyJudge/adless/internal/lock"
+	"github.com/WIttyJudge/adless/internal/state"
+	"github.com/WIttyJudge/adless/pkg/diff"
 
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 )
 
@@ -62,37 +70,142 @@ func (a *Action) GetCommands() []*cli.Command {
 				},
 			},
 		},
+		{
+			Name:  "backup",
+			Usage: "Manage backups of the hosts file",
+			Description: "" +
+				"Every command that modifies the hosts file saves a new backup " +
+				"generation of the hosts file before modifying it.\n" +
+				"Besides that, the pristine copy of the hosts file, taken before adless " +
+				"modified it for the first time, is kept forever.",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "list",
+					Usage:  "List backups of the hosts file",
+					Action: a.BackupList,
+				},
+				{
+					Name:      "show",
+					Usage:     "Print the content of the backup",
+					ArgsUsage: "<id>",
+					Action:    a.BackupShow,
+				},
+			},
+		},
+		{
+			Name:      "block",
+			Usage:     "Add domains to the blocklist of the configuration file",
+			ArgsUsage: "<domain...>",
+			Description: "" +
+				"Adds the domains to inline domains of blocklists in the configuration file " +
+				"and removes them from inline domains of whitelists.\n" +
+				"Comments of the configuration file are preserved. Run `update` command to apply the changes.",
+			Action: a.Block,
+		},
+		{
+			Name:      "unblock",
+			Usage:     "Add domains to the whitelist of the configuration file",
+			ArgsUsage: "<domain...>",
+			Description: "" +
+				"Adds the domains to inline domains of whitelists in the configuration file " +
+				"and removes them from inline domains of blocklists. Wildcards like *.example.com " +
+				"unblock subdomains as well.\n" +
+				"Comments of the configuration file are preserved. Run `update` command to apply the changes.",
+			Action: a.Unblock,
+		},
 		{
 			Name:   "disable",
 			Usage:  "Disable domains blocking",
 			Action: a.Disable,
+			Flags: []cli.Flag{
+				dryRunFlag(),
+			},
 		},
 		{
 			Name:   "enable",
 			Usage:  "Enable domains blocking",
 			Action: a.Enable,
+			Flags: []cli.Flag{
+				dryRunFlag(),
+			},
 		},
-
 		{
-			Name:   "status",
-			Usage:  "Check if domains blocking enabled or not",
+			Name:  "status",
+			Usage: "Check if domains blocking enabled or not",
+			Description: "" +
+				"Reports whether domains blocking is enabled, the number of blocked domains, " +
+				"the lists they came from and whether the block in the hosts file has been " +
+				"changed since adless wrote it.\n" +
+				"Exits with a non-zero code when domains blocking is disabled.",
 			Action: a.Status,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   "Output format: text or json",
+					Value:   "text",
+				},
+			},
 		},
 		{
 			Name:   "update",
 			Usage:  "Update the list of domains to be blocked",
 			Action: a.Update,
+			Flags: []cli.Flag{
+				dryRunFlag(),
+			},
+		},
+		{
+			Name:  "verify",
+			Usage: "Verify integrity of domains blocking in the hosts file",
+			Description: "" +
+				"Checks the block of blocked domains in the hosts file against the checksum " +
+				"written to its header and reports whether it's intact, modified, truncated " +
+				"or duplicated.\n" +
+				"Exit codes: 0 - intact, 4 - missing, 5 - modified, 6 - truncated, " +
+				"7 - duplicated, 8 - unverifiable (written without checksum).",
+			Action: a.Verify,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:               "fix",
+					Usage:              "Rebuild domains blocking if it isn't intact",
+					DisableDefaultText: true,
+				},
+				dryRunFlag(),
+			},
+		},
+		{
+			Name:  "repair",
+			Usage: "Repair malformed or duplicated domains blocking in the hosts file",
+			Description: "" +
+				"Looks for duplicated blocks of blocked domains, start and end tags without " +
+				"each other and end tags placed before start tags, then fixes them, so the hosts " +
+				"file ends up with exactly one valid block or none.\n" +
+				"A backup of the hosts file is saved before repairing.",
+			Action: a.Repair,
+			Flags: []cli.Flag{
+				dryRunFlag(),
+			},
 		},
 		{
 			Name:  "restore",
 			Usage: "Restore hosts file from backup to its previous state",
 			Description: "" +
-				"When a `enable`, `disable` or `update` command is invoked, it creates a backup of the " +
-				"original hosts file by copying it to backup file (hosts.backup).\n" +
-				"The `restore` command copies the backup file (hosts.backup) back to its " +
-				"original location (hosts).\n" +
-				"Backup file must already exist to perform a command successfully.",
+				"When a `enable`, `disable` or `update` command is invoked, it saves a new backup " +
+				"generation of the hosts file.\n" +
+				"By default, the `restore` command restores the latest backup generation, " +
+				"except the ones saved by `restore` itself, " +
+				"use `--to` option to restore a specific one listed by `backup list` command " +
+				"or the pristine copy of the hosts file.\n" +
+				"Backup must already exist to perform a command successfully.",
 			Action: a.Restore,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "to",
+					Usage: "`ID` of the backup to restore or pristine",
+				},
+				dryRunFlag(),
+			},
 		},
 	}
 }
@@ -103,6 +216,19 @@ func (a *Action) GetFlags() []cli.Flag {
 			Name:  "config-file",
 			Usage: "Path to the configuration file",
 		},
+		&cli.StringFlag{
+			Name:  "hosts-file",
+			Usage: "Path to the hosts file to manage instead of the system one",
+		},
+		&cli.DurationFlag{
+			Name:  "lock-timeout",
+			Usage: "How long to wait for another running adless process to finish",
+		},
+		&cli.BoolFlag{
+			Name:               "wait",
+			Usage:              "Wait for another running adless process to finish without a timeout",
+			DisableDefaultText: true,
+		},
 		&cli.BoolFlag{
 			Name:               "verbose",
 			Aliases:            []string{"v"},
@@ -118,6 +244,14 @@ func (a *Action) GetFlags() []cli.Flag {
 	}
 }
 
+func dryRunFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:               "dry-run",
+		Usage:              "Print changes to the hosts file without writing them",
+		DisableDefaultText: true,
+	}
+}
+
 func (a *Action) loadConfig(ctx *cli.Context) error {
 	var (
 		cfg *config.Config
@@ -140,3 +274,67 @@ func (a *Action) loadConfig(ctx *cli.Context) error {
 
 	return nil
 }
+
+// hostsFile opens the hosts file that has to be managed.
+// The location provided via hosts-file CLI flag has a priority over
+// the one from the config file.
+func (a *Action) hostsFile(ctx *cli.Context) (*hostsfile.File, error) {
+	location := ctx.String("hosts-file")
+	if location == "" {
+		location = a.config.HostsFile
+	}
+
+	return hostsfile.New(location, a.config.Backup.Retention)
+}
+
+// acquireLock acquires the lock that prevents multiple adless processes
+// from modifying the hosts file at the same time.
+func (a *Action) acquireLock(ctx *cli.Context) (*lock.Lock, error) {
+	timeout := ctx.Duration("lock-timeout")
+	if ctx.Bool("wait") {
+		timeout = lock.WaitForever
+	}
+
+	return lock.Acquire(filepath.Join(state.Dir(), "adless.lock"), timeout)
+}
+
+// preview prints the difference between the current and the new content of
+// the hosts file in the unified format, along with the number of domains
+// that would be blocked and unblocked. Domains of the main blocks are
+// compared, the lines they start at are logged, zero if there is no block.
+func (a *Action) preview(hosts *hostsfile.File, content, newContent string) {
+	domains, line := hostsfile.BlockedDomains(content)
+	newDomains, newLine := hostsfile.BlockedDomains(newContent)
+
+	added, removed := domainsDiff(domains, newDomains)
+
+	log.Info().
+		Int("block", added).
+		Int("unblock", removed).
+		Int("currentBlockLine", line).
+		Int("newBlockLine", newLine).
+		Msg("dry run, the hosts file is left untouched")
+
+	fmt.Print(diff.Unified(hosts.Location(), hosts.Location(), content, newContent, diff.DefaultContext))
+}
+
+// domainsDiff returns the number of domains added to and removed from
+// the old domains.
+func domainsDiff(oldDomains, newDomains []string) (int, int) {
+	oldSet := make(map[string]struct{}, len(oldDomains))
+	for _, domain := range oldDomains {
+		oldSet[domain] = struct{}{}
+	}
+
+	added := 0
+	for _, domain := range newDomains {
+		if _, ok := oldSet[domain]; ok {
+			delete(oldSet, domain)
+			continue
+		}
+
+		added++
+	}
+
+	return added, len(oldSet)
+}
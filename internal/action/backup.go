@@ -0,0 +1,56 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WIttyJudge/adless/internal/action/exit"
+
+	"github.com/urfave/cli/v2"
+)
+
+func (a *Action) BackupList(ctx *cli.Context) error {
+	hosts, err := a.hostsFile(ctx)
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
+	}
+
+	backups, err := hosts.Backups().List()
+	if err != nil {
+		return exit.Error(exit.Backup, err, "failed to list backups")
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(writer, "ID\tCREATED\tCOMMAND\tSIZE")
+
+	for _, backup := range backups {
+		createdAt := backup.CreatedAt.Local().Format(time.DateTime)
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\n", backup.ID, createdAt, backup.Command, backup.Size)
+	}
+
+	return writer.Flush()
+}
+
+func (a *Action) BackupShow(ctx *cli.Context) error {
+	id := ctx.Args().First()
+	if id == "" {
+		return exit.Error(exit.Backup, errors.New("backup ID is required"), "failed to show backup")
+	}
+
+	hosts, err := a.hostsFile(ctx)
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
+	}
+
+	content, err := hosts.Backups().Content(id)
+	if err != nil {
+		return exit.Error(exit.Backup, err, "failed to show backup")
+	}
+
+	fmt.Print(string(content))
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/WIttyJudge/adless/internal/action/exit"
+	"github.com/WIttyJudge/adless/internal/config"
+	"github.com/WIttyJudge/adless/pkg/parser"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func (a *Action) Block(ctx *cli.Context) error {
+	domains, err := domainArgs(ctx, false)
+	if err != nil {
+		return exit.Error(exit.Config, err, "failed to block domains")
+	}
+
+	if err := config.BlockDomains(a.config.Path, domains); err != nil {
+		return exit.Error(exit.Config, err, "failed to block domains")
+	}
+
+	log.Info().Msg("domains added to the config file, run `adless update` to apply the changes")
+
+	return nil
+}
+
+func (a *Action) Unblock(ctx *cli.Context) error {
+	domains, err := domainArgs(ctx, true)
+	if err != nil {
+		return exit.Error(exit.Config, err, "failed to unblock domains")
+	}
+
+	if err := config.UnblockDomains(a.config.Path, domains); err != nil {
+		return exit.Error(exit.Config, err, "failed to unblock domains")
+	}
+
+	log.Info().Msg("domains added to the config file, run `adless update` to apply the changes")
+
+	return nil
+}
+
+// domainArgs returns lowercased domains passed as arguments of the command.
+// Wildcards like *.example.com are accepted only if they're allowed,
+// as the hosts file can't block subdomains.
+func domainArgs(ctx *cli.Context, wildcards bool) ([]string, error) {
+	if ctx.NArg() == 0 {
+		return nil, fmt.Errorf("no domains provided")
+	}
+
+	domains := make([]string, 0, ctx.NArg())
+	for _, arg := range ctx.Args().Slice() {
+		domain := strings.ToLower(strings.TrimSpace(arg))
+
+		name := domain
+		if wildcards {
+			name = strings.TrimPrefix(strings.TrimPrefix(name, "*"), ".")
+		}
+
+		if !parser.IsValidDomain(name) || parser.IsReservedDomain(name) {
+			return nil, fmt.Errorf("invalid domain: %q", arg)
+		}
+
+		domains = append(domains, domain)
+	}
+
+	return domains, nil
+}
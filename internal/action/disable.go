@@ -8,8 +8,14 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func (a *Action) Disable(_ *cli.Context) error {
-	hosts, err := hostsfile.New()
+func (a *Action) Disable(ctx *cli.Context) error {
+	lock, err := a.acquireLock(ctx)
+	if err != nil {
+		return exit.Error(exit.Lock, err, "failed to lock hosts file")
+	}
+	defer lock.Release()
+
+	hosts, err := a.hostsFile(ctx)
 	if err != nil {
 		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
 	}
@@ -19,11 +25,26 @@ func (a *Action) Disable(_ *cli.Context) error {
 		return nil
 	}
 
-	if err := hosts.Backup(); err != nil {
+	content, err := hosts.Content()
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to read hosts file")
+	}
+
+	newContent, err := hostsfile.RemoveBlock(content)
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to disable domains blocking")
+	}
+
+	if ctx.Bool("dry-run") {
+		a.preview(hosts, content, newContent)
+		return nil
+	}
+
+	if err := hosts.Backup(ctx.Command.Name); err != nil {
 		return exit.Error(exit.HostsFile, err, "failed to backup hosts file")
 	}
 
-	if err := hosts.RemoveDomainsBlocking(); err != nil {
+	if err := hosts.Rewrite(newContent); err != nil {
 		return exit.Error(exit.HostsFile, err, "failed to disable domains blocking")
 	}
 
@@ -8,8 +8,14 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func (a *Action) Enable(_ *cli.Context) error {
-	hosts, err := hostsfile.New()
+func (a *Action) Enable(ctx *cli.Context) error {
+	lock, err := a.acquireLock(ctx)
+	if err != nil {
+		return exit.Error(exit.Lock, err, "failed to lock hosts file")
+	}
+	defer lock.Release()
+
+	hosts, err := a.hostsFile(ctx)
 	if err != nil {
 		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
 	}
@@ -19,21 +25,18 @@ func (a *Action) Enable(_ *cli.Context) error {
 		return nil
 	}
 
-	if err := hosts.Backup(); err != nil {
-		return exit.Error(exit.HostsFile, err, "failed to backup hosts file")
+	content, err := hosts.Content()
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to read hosts file")
 	}
 
-	processor := hostsfile.NewProcessor(a.config)
-	parsedBlocklists, err := processor.Process()
-	if err != nil {
+	if _, err := a.writeBlock(ctx, hosts, content); err != nil {
 		return err
 	}
 
-	if err := hosts.Write(parsedBlocklists.FormatToHostsfile()); err != nil {
-		return exit.Error(exit.HostsFile, err, "failed to write to hosts file")
+	if !ctx.Bool("dry-run") {
+		log.Info().Msg("domain blocking successfully enabled")
 	}
 
-	log.Info().Msg("domain blocking successfully enabled")
-
 	return nil
 }
@@ -11,6 +11,19 @@ const (
 	Unknown = iota
 	Config
 	HostsFile
+	Lock
+	// BlockingDisabled is returned by status command when domains blocking
+	// is disabled, so scripts can check it.
+	BlockingDisabled
+	// Block* codes are returned by verify command when the block of blocked
+	// domains in the hosts file isn't intact.
+	BlockModified
+	BlockTruncated
+	BlockDuplicated
+	BlockUnverifiable
+	// Backup is returned by backup commands when the backup can't be found
+	// or read.
+	Backup
 )
 
 // Error returns a user friendly CLI error.
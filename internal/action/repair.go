@@ -0,0 +1,60 @@
+package action
+
+import (
+	"github.com/WIttyJudge/adless/internal/action/exit"
+	"github.com/WIttyJudge/adless/internal/hostsfile"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+func (a *Action) Repair(ctx *cli.Context) error {
+	lock, err := a.acquireLock(ctx)
+	if err != nil {
+		return exit.Error(exit.Lock, err, "failed to lock hosts file")
+	}
+	defer lock.Release()
+
+	hosts, err := a.hostsFile(ctx)
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
+	}
+
+	content, err := hosts.Content()
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to read hosts file")
+	}
+
+	problems := hostsfile.Diagnose(content)
+	if len(problems) == 0 {
+		log.Info().Msg("no problems found in the hosts file")
+		return nil
+	}
+
+	for _, problem := range problems {
+		log.Warn().Int("line", problem.Line).Msg(string(problem.Kind))
+	}
+
+	newContent := hostsfile.Repair(content)
+
+	if ctx.Bool("dry-run") {
+		a.preview(hosts, content, newContent)
+		return nil
+	}
+
+	if err := hosts.Backup(ctx.Command.Name); err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to backup hosts file")
+	}
+
+	if err := hosts.Rewrite(newContent); err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to repair hosts file")
+	}
+
+	if hosts.Status() == hostsfile.Enabled {
+		log.Info().Msg("hosts file successfully repaired, domains blocking is enabled")
+	} else {
+		log.Info().Msg("hosts file successfully repaired, run `adless enable` to enable domains blocking again")
+	}
+
+	return nil
+}
@@ -2,22 +2,43 @@ package action
 
 import (
 	"github.com/WIttyJudge/adless/internal/action/exit"
-	"github.com/WIttyJudge/adless/internal/hostsfile"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/urfave/cli/v2"
 )
 
-func (a *Action) Restore(_ *cli.Context) error {
-	log.Info().Msg("restoring hosts file from backup..")
+func (a *Action) Restore(ctx *cli.Context) error {
+	lock, err := a.acquireLock(ctx)
+	if err != nil {
+		return exit.Error(exit.Lock, err, "failed to lock hosts file")
+	}
+	defer lock.Release()
 
-	hosts, err := hostsfile.New()
+	hosts, err := a.hostsFile(ctx)
 	if err != nil {
 		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
 	}
 
-	if err := hosts.Restore(); err != nil {
+	if ctx.Bool("dry-run") {
+		content, err := hosts.Content()
+		if err != nil {
+			return exit.Error(exit.HostsFile, err, "failed to read hosts file")
+		}
+
+		backupContent, err := hosts.BackupContent(ctx.String("to"))
+		if err != nil {
+			return exit.Error(exit.HostsFile, err, "failed to restore hosts file")
+		}
+
+		a.preview(hosts, content, backupContent)
+
+		return nil
+	}
+
+	log.Info().Msg("restoring hosts file from backup..")
+
+	if err := hosts.Restore(ctx.String("to")); err != nil {
 		return exit.Error(exit.HostsFile, err, "failed to restore hosts file")
 	}
 
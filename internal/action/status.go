@@ -1,6 +1,13 @@
 package action
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
 	"github.com/WIttyJudge/adless/internal/action/exit"
 	"github.com/WIttyJudge/adless/internal/hostsfile"
 
@@ -8,18 +15,151 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func (a *Action) Status(_ *cli.Context) error {
-	hosts, err := hostsfile.New()
+// statusReport is the output of status command.
+type statusReport struct {
+	Status        string             `json:"status"`
+	HostsFile     string             `json:"hostsFile"`
+	DomainsCount  int                `json:"domainsCount"`
+	MappingsCount int                `json:"mappingsCount,omitempty"`
+	Version       string             `json:"version,omitempty"`
+	GeneratedAt   *time.Time         `json:"generatedAt,omitempty"`
+	ConfigPath    string             `json:"configPath,omitempty"`
+	Blocklists    []hostsfile.Source `json:"blocklists,omitempty"`
+	Whitelists    []hostsfile.Source `json:"whitelists,omitempty"`
+	Hosts         []hostsfile.Source `json:"hosts,omitempty"`
+	LatestBackup  string             `json:"latestBackup,omitempty"`
+	Drift         hostsfile.Drift    `json:"drift"`
+}
+
+func (a *Action) Status(ctx *cli.Context) error {
+	output := ctx.String("output")
+	if output != "text" && output != "json" {
+		return exit.Error(exit.Config, fmt.Errorf("unsupported output format: %s", output), "failed to check status")
+	}
+
+	hosts, err := a.hostsFile(ctx)
 	if err != nil {
 		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
 	}
 
-	if hosts.Status() == hostsfile.Enabled {
-		log.Info().Msg("domains blocking enabled")
-		return nil
+	report, err := a.statusReport(hosts)
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to check status")
+	}
+
+	if output == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(out))
+	} else if err := printStatusReport(report); err != nil {
+		return err
 	}
 
-	log.Info().Msg("domains blocking disabled")
+	if report.Status == hostsfile.Disabled.String() {
+		return cli.Exit("", exit.BlockingDisabled)
+	}
 
 	return nil
 }
+
+func (a *Action) statusReport(hosts *hostsfile.File) (statusReport, error) {
+	content, err := hosts.Content()
+	if err != nil {
+		return statusReport{}, err
+	}
+
+	report := statusReport{
+		Status:       hosts.Status().String(),
+		HostsFile:    hosts.Location(),
+		DomainsCount: len(hostsfile.Domains(content)),
+		Drift:        hosts.Drift(content),
+	}
+
+	// The header of the block describes how it was generated. The record of
+	// the last write is used for blocks written without the header.
+	header, err := hostsfile.ParseHeader(content)
+	if err != nil && !errors.Is(err, hostsfile.ErrStartTagNotFound) {
+		log.Warn().Err(err).Msg("failed to parse header of the block")
+	}
+
+	if err == nil && !header.GeneratedAt.IsZero() {
+		// Mapped domains are in the block too, but they aren't blocked.
+		report.DomainsCount -= header.MappingsCount
+		report.MappingsCount = header.MappingsCount
+		report.Version = header.Version
+		report.GeneratedAt = &header.GeneratedAt
+		report.ConfigPath = header.ConfigPath
+		report.Blocklists = header.Blocklists
+		report.Whitelists = header.Whitelists
+		report.Hosts = header.Hosts
+	} else if record, err := hosts.Record(); err == nil {
+		report.GeneratedAt = &record.GeneratedAt
+		report.Blocklists = record.Blocklists
+		report.Whitelists = record.Whitelists
+		report.Hosts = record.Hosts
+	} else if !errors.Is(err, hostsfile.ErrNoRecord) {
+		log.Warn().Err(err).Msg("failed to read record of the written domains")
+	}
+
+	if latest, err := hosts.Backups().Latest(); err == nil {
+		report.LatestBackup = latest.ID
+	}
+
+	return report, nil
+}
+
+func printStatusReport(report statusReport) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(writer, "Status:\t%s\n", report.Status)
+	fmt.Fprintf(writer, "Hosts file:\t%s\n", report.HostsFile)
+	fmt.Fprintf(writer, "Blocked domains:\t%d\n", report.DomainsCount)
+
+	if report.MappingsCount > 0 {
+		fmt.Fprintf(writer, "Mapped domains:\t%d\n", report.MappingsCount)
+	}
+
+	if report.GeneratedAt != nil {
+		fmt.Fprintf(writer, "Generated at:\t%s\n", report.GeneratedAt.Local().Format(time.DateTime))
+	}
+
+	if report.Version != "" {
+		fmt.Fprintf(writer, "Generated by:\tadless %s\n", report.Version)
+	}
+
+	if report.ConfigPath != "" {
+		fmt.Fprintf(writer, "Config file:\t%s\n", report.ConfigPath)
+	}
+
+	for _, source := range report.Blocklists {
+		fmt.Fprintf(writer, "Blocklist:\t%s\n", formatSource(source))
+	}
+
+	for _, source := range report.Whitelists {
+		fmt.Fprintf(writer, "Whitelist:\t%s\n", formatSource(source))
+	}
+
+	for _, source := range report.Hosts {
+		fmt.Fprintf(writer, "Hosts:\t%s\n", formatSource(source))
+	}
+
+	latestBackup := report.LatestBackup
+	if latestBackup == "" {
+		latestBackup = "none"
+	}
+	fmt.Fprintf(writer, "Latest backup:\t%s\n", latestBackup)
+	fmt.Fprintf(writer, "Drift:\t%s\n", report.Drift)
+
+	return writer.Flush()
+}
+
+func formatSource(source hostsfile.Source) string {
+	if source.Error != "" {
+		return source.Target + " (failed)"
+	}
+
+	return fmt.Sprintf("%s (%d domains)", source.Target, source.DomainsCount)
+}
@@ -8,34 +8,86 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func (a *Action) Update(_ *cli.Context) error {
-	processor := hostsfile.NewProcessor(a.config)
+func (a *Action) Update(ctx *cli.Context) error {
+	lock, err := a.acquireLock(ctx)
+	if err != nil {
+		return exit.Error(exit.Lock, err, "failed to lock hosts file")
+	}
+	defer lock.Release()
 
-	hosts, err := hostsfile.New()
+	hosts, err := a.hostsFile(ctx)
 	if err != nil {
 		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
 	}
 
-	if err := hosts.Backup(); err != nil {
-		return exit.Error(exit.HostsFile, err, "failed to backup hosts file")
+	content, err := hosts.Content()
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to read hosts file")
 	}
 
-	if hosts.Status() == hostsfile.Enabled {
-		if err := hosts.RemoveDomainsBlocking(); err != nil {
-			return exit.Error(exit.HostsFile, err, "failed to update domains blocking")
-		}
+	changed, err := a.writeBlock(ctx, hosts, content)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		log.Info().Msg("domains blocking is already up to date")
+		return nil
+	}
+
+	if !ctx.Bool("dry-run") {
+		log.Info().Msg("domains blocking successfully updated and enabled")
 	}
 
+	return nil
+}
+
+// writeBlock processes blocklists and writes the block of blocked domains
+// to the hosts file with content, replacing the existing block.
+// The hosts file isn't rewritten if the block hasn't changed, in this case
+// it returns false. In dry-run mode, it only prints the changes.
+func (a *Action) writeBlock(ctx *cli.Context, hosts *hostsfile.File, content string) (bool, error) {
+	processor := hostsfile.NewProcessor(a.config, ctx.App.Version)
+	processor.SetHostsEntries(hostsfile.Parse(content).Entries())
+
 	parsedBlocklists, err := processor.Process()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if err := hosts.Write(parsedBlocklists.FormatToHostsfile()); err != nil {
-		return exit.Error(exit.HostsFile, err, "failed to write to hosts file")
+	for _, conflict := range parsedBlocklists.Conflicts() {
+		event := log.Warn().Str("domain", conflict.Domain).Str("ip", conflict.IP)
+		if conflict.Whitelisted {
+			event.Msg("domain is mapped in the hosts file, so it isn't blocked")
+		} else {
+			event.Msg("domain is mapped in the hosts file and blocked, resolution depends on the order of lines")
+		}
 	}
 
-	log.Info().Msg("domains blocking successfully updated and enabled")
+	block := parsedBlocklists.FormatToHostsfile()
 
-	return nil
+	newContent := hostsfile.AddBlock(content, block)
+
+	if hostsfile.SameContent(content, newContent) {
+		return false, nil
+	}
+
+	if ctx.Bool("dry-run") {
+		a.preview(hosts, content, newContent)
+		return true, nil
+	}
+
+	if err := hosts.Backup(ctx.Command.Name); err != nil {
+		return false, exit.Error(exit.HostsFile, err, "failed to backup hosts file")
+	}
+
+	if err := hosts.Rewrite(newContent); err != nil {
+		return false, exit.Error(exit.HostsFile, err, "failed to write to hosts file")
+	}
+
+	if err := hosts.SaveRecord(hostsfile.NewRecord(parsedBlocklists, block)); err != nil {
+		log.Warn().Err(err).Msg("failed to save record of the written domains")
+	}
+
+	return true, nil
 }
@@ -0,0 +1,72 @@
+package action
+
+import (
+	"github.com/WIttyJudge/adless/internal/action/exit"
+	"github.com/WIttyJudge/adless/internal/hostsfile"
+
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+)
+
+// integrityExitCodes maps results of the verification to exit codes.
+var integrityExitCodes = map[hostsfile.Integrity]int{
+	hostsfile.IntegrityMissing:      exit.BlockingDisabled,
+	hostsfile.IntegrityModified:     exit.BlockModified,
+	hostsfile.IntegrityTruncated:    exit.BlockTruncated,
+	hostsfile.IntegrityDuplicated:   exit.BlockDuplicated,
+	hostsfile.IntegrityUnverifiable: exit.BlockUnverifiable,
+}
+
+func (a *Action) Verify(ctx *cli.Context) error {
+	hosts, err := a.hostsFile(ctx)
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to process hosts file")
+	}
+
+	content, err := hosts.Content()
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to read hosts file")
+	}
+
+	integrity := hostsfile.Verify(content)
+
+	if integrity == hostsfile.IntegrityIntact {
+		log.Info().Str("integrity", string(integrity)).Msg("domains blocking is intact")
+		return nil
+	}
+
+	log.Warn().Str("integrity", string(integrity)).Msg("domains blocking is not intact")
+
+	if !ctx.Bool("fix") || integrity == hostsfile.IntegrityMissing {
+		return cli.Exit("", integrityExitCodes[integrity])
+	}
+
+	return a.rebuildBlock(ctx, hosts)
+}
+
+// rebuildBlock replaces all the blocks in the hosts file, including broken
+// ones, with a new one.
+func (a *Action) rebuildBlock(ctx *cli.Context, hosts *hostsfile.File) error {
+	lock, err := a.acquireLock(ctx)
+	if err != nil {
+		return exit.Error(exit.Lock, err, "failed to lock hosts file")
+	}
+	defer lock.Release()
+
+	// The hosts file is read again, since it could be changed before
+	// the lock was acquired.
+	content, err := hosts.Content()
+	if err != nil {
+		return exit.Error(exit.HostsFile, err, "failed to read hosts file")
+	}
+
+	if _, err := a.writeBlock(ctx, hosts, content); err != nil {
+		return err
+	}
+
+	if !ctx.Bool("dry-run") {
+		log.Info().Msg("domains blocking successfully rebuilt")
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/WIttyJudge/adless/internal/state"
+	"github.com/WIttyJudge/adless/pkg/fsutil"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// PristineID is the ID of the snapshot of the hosts file taken before
+	// adless modified it for the first time. It's never removed.
+	PristineID = "pristine"
+
+	// RestoreCommand is the command of backups made before restoring
+	// the hosts file. They're ignored by Latest, so restoring twice doesn't
+	// undo the first restore.
+	RestoreCommand = "restore"
+
+	// DefaultRetention is the number of backup generations kept by default.
+	DefaultRetention = 10
+
+	idLayout          = "20060102-150405"
+	contentExtension  = ".hosts"
+	metadataExtension = ".json"
+)
+
+var ErrNotFound = errors.New("backup not found")
+
+// Backup describes a single copy of the hosts file.
+type Backup struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	CreatedAt time.Time `json:"createdAt"`
+	Size      int       `json:"size"`
+}
+
+// Store keeps multiple generations of hosts file backups in a directory.
+type Store struct {
+	dir       string
+	retention int
+}
+
+// Dir returns the directory for backups of the hosts file at location.
+// Each hosts file has its own directory, so backups of different files
+// never get mixed.
+func Dir(location string) string {
+	return filepath.Join(state.Dir(), "backups", state.Key(location))
+}
+
+// NewStore returns a store that keeps retention generations of backups in dir.
+func NewStore(dir string, retention int) *Store {
+	return &Store{
+		dir:       dir,
+		retention: retention,
+	}
+}
+
+// Save saves content as a new backup generation made by the command and
+// removes the generations exceeding the retention.
+func (s *Store) Save(content []byte, command string) (Backup, error) {
+	return s.Import(content, command, time.Now())
+}
+
+// Import saves content as a backup generation made by the command
+// at the time of creation, the same way as Save.
+func (s *Store) Import(content []byte, command string, createdAt time.Time) (Backup, error) {
+	backup := Backup{
+		ID:        s.newID(createdAt),
+		Command:   command,
+		CreatedAt: createdAt,
+		Size:      len(content),
+	}
+
+	if err := s.write(backup, content); err != nil {
+		return Backup{}, err
+	}
+
+	log.Debug().Str("id", backup.ID).Str("location", s.dir).Msg("backup saved")
+
+	if err := s.prune(); err != nil {
+		return Backup{}, fmt.Errorf("failed to remove old backups: %w", err)
+	}
+
+	return backup, nil
+}
+
+// HasPristine checks if the pristine snapshot has already been taken.
+func (s *Store) HasPristine() bool {
+	_, err := os.Stat(s.contentPath(PristineID))
+	return err == nil
+}
+
+// SavePristine saves the pristine snapshot if it doesn't exist yet.
+func (s *Store) SavePristine(content []byte) error {
+	if s.HasPristine() {
+		return nil
+	}
+
+	backup := Backup{
+		ID:        PristineID,
+		Command:   "pristine",
+		CreatedAt: time.Now(),
+		Size:      len(content),
+	}
+
+	return s.write(backup, content)
+}
+
+// List returns all the backups from the newest to the oldest.
+// The pristine snapshot always goes last.
+func (s *Store) List() ([]Backup, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+metadataExtension))
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]Backup, 0, len(paths))
+	for _, path := range paths {
+		backup, err := s.readMetadata(path)
+		if err != nil {
+			return nil, err
+		}
+
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].ID == PristineID || backups[j].ID == PristineID {
+			return backups[j].ID == PristineID
+		}
+
+		return backups[i].ID > backups[j].ID
+	})
+
+	return backups, nil
+}
+
+// Get returns the backup by its ID.
+func (s *Store) Get(id string) (Backup, error) {
+	backup, err := s.readMetadata(s.metadataPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return Backup{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	return backup, err
+}
+
+// Latest returns the most recent backup generation,
+// skipping the ones made before restoring.
+func (s *Store) Latest() (Backup, error) {
+	backups, err := s.List()
+	if err != nil {
+		return Backup{}, err
+	}
+
+	for _, backup := range backups {
+		if backup.ID != PristineID && backup.Command != RestoreCommand {
+			return backup, nil
+		}
+	}
+
+	return Backup{}, ErrNotFound
+}
+
+// Content returns content of the backup by its ID.
+func (s *Store) Content(id string) ([]byte, error) {
+	content, err := os.ReadFile(s.contentPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	return content, err
+}
+
+func (s *Store) write(backup Backup, content []byte) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+
+	metadata, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The content goes first, so a metadata file never points to
+	// a missing backup.
+	if err := fsutil.WriteFile(s.contentPath(backup.ID), content); err != nil {
+		return err
+	}
+
+	return fsutil.WriteFile(s.metadataPath(backup.ID), metadata)
+}
+
+// prune removes the oldest backup generations exceeding the retention.
+func (s *Store) prune() error {
+	backups, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	generations := 0
+	for _, backup := range backups {
+		if backup.ID == PristineID {
+			continue
+		}
+
+		generations++
+		if generations <= s.retention {
+			continue
+		}
+
+		log.Debug().Str("id", backup.ID).Msg("removing old backup")
+
+		if err := os.Remove(s.metadataPath(backup.ID)); err != nil {
+			return err
+		}
+
+		if err := os.Remove(s.contentPath(backup.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newID returns the ID based on the time of creation.
+// A suffix is added if there is already a backup made in the same second.
+func (s *Store) newID(createdAt time.Time) string {
+	base := createdAt.UTC().Format(idLayout)
+
+	id := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(s.metadataPath(id)); errors.Is(err, os.ErrNotExist) {
+			return id
+		}
+
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (s *Store) readMetadata(path string) (Backup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return Backup{}, fmt.Errorf("failed to read backup %s: %w", path, err)
+	}
+
+	return backup, nil
+}
+
+func (s *Store) contentPath(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+contentExtension)
+}
+
+func (s *Store) metadataPath(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+metadataExtension)
+}
@@ -0,0 +1,100 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("saves and lists backups from the newest", func(t *testing.T) {
+		store := NewStore(t.TempDir(), DefaultRetention)
+
+		first, err := store.Save([]byte("first"), "enable")
+		require.NoError(t, err)
+		second, err := store.Save([]byte("second"), "update")
+		require.NoError(t, err)
+
+		backups, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, backups, 2)
+		assert.Equal(t, second.ID, backups[0].ID)
+		assert.Equal(t, first.ID, backups[1].ID)
+		assert.Equal(t, "update", backups[0].Command)
+
+		content, err := store.Content(first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(content))
+	})
+
+	t.Run("removes generations exceeding retention", func(t *testing.T) {
+		store := NewStore(t.TempDir(), 2)
+		require.NoError(t, store.SavePristine([]byte("pristine")))
+
+		for range 3 {
+			_, err := store.Save([]byte("content"), "update")
+			require.NoError(t, err)
+		}
+
+		backups, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, backups, 3)
+		assert.Equal(t, PristineID, backups[2].ID)
+	})
+
+	t.Run("keeps the first pristine snapshot", func(t *testing.T) {
+		store := NewStore(t.TempDir(), DefaultRetention)
+
+		require.NoError(t, store.SavePristine([]byte("original")))
+		require.NoError(t, store.SavePristine([]byte("modified")))
+
+		content, err := store.Content(PristineID)
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(content))
+	})
+
+	t.Run("latest ignores pristine snapshot", func(t *testing.T) {
+		store := NewStore(t.TempDir(), DefaultRetention)
+		require.NoError(t, store.SavePristine([]byte("original")))
+
+		_, err := store.Latest()
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("latest ignores backups made before restoring", func(t *testing.T) {
+		store := NewStore(t.TempDir(), DefaultRetention)
+
+		update, err := store.Save([]byte("update"), "update")
+		require.NoError(t, err)
+		_, err = store.Save([]byte("before restore"), RestoreCommand)
+		require.NoError(t, err)
+
+		latest, err := store.Latest()
+		require.NoError(t, err)
+		assert.Equal(t, update.ID, latest.ID)
+	})
+
+	t.Run("imports backup made earlier", func(t *testing.T) {
+		store := NewStore(t.TempDir(), DefaultRetention)
+
+		_, err := store.Save([]byte("update"), "update")
+		require.NoError(t, err)
+		imported, err := store.Import([]byte("legacy"), "legacy", time.Date(2024, 10, 17, 10, 15, 0, 0, time.UTC))
+		require.NoError(t, err)
+
+		backups, err := store.List()
+		require.NoError(t, err)
+		require.Len(t, backups, 2)
+		assert.Equal(t, "20241017-101500", imported.ID)
+		assert.Equal(t, imported.ID, backups[1].ID)
+	})
+
+	t.Run("returns error for unknown backup", func(t *testing.T) {
+		store := NewStore(t.TempDir(), DefaultRetention)
+
+		_, err := store.Get("unknown")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
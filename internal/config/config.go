@@ -7,6 +7,7 @@ import (
 	"os/user"
 	"path/filepath"
 
+	"github.com/WIttyJudge/adless/internal/backup"
 	"github.com/charmbracelet/x/editor"
 	"github.com/rs/zerolog/log"
 
@@ -23,10 +24,130 @@ type Config struct {
 	// Adding some domains to whitelist may fix many problems like YouTube
 	// watch history, videos on news sites and so on.
 	Whitelists []Domainlist `yaml:"whitelists"`
+
+	// WhitelistHostsEntries enables treating domains the user maps in the hosts
+	// file outside of the block as whitelisted, so local overrides keep working.
+	WhitelistHostsEntries bool `yaml:"whitelist_hosts_entries"`
+
+	// Hosts are domains mapped to specific addresses, i.e. local development
+	// hosts or safe search redirects. They're written to the block and take
+	// precedence over blocklists and whitelists.
+	Hosts []Host `yaml:"hosts,omitempty"`
+
+	// Regex are rules of regular expressions matching domains.
+	Regex Regex `yaml:"regex,omitempty"`
+
+	// HostsFile is the path to the hosts file that adless manages.
+	// If it's empty, the hosts file of the operating system is used.
+	HostsFile string `yaml:"hosts_file,omitempty"`
+
+	// Backup configures how backups of the hosts file are kept.
+	Backup Backup `yaml:"backup"`
+
+	// Output configures how blocked domains are written to the hosts file.
+	Output Output `yaml:"output"`
+
+	// Path is the location of the loaded config file.
+	// It's empty if the default config is used.
+	Path string `yaml:"-"`
 }
 
 type Domainlist struct {
-	Target string `yaml:"target"`
+	Target string `yaml:"target,omitempty"`
+
+	// Entry is the name or the glob pattern of files to read from the zip
+	// archive of the target. If it's empty, all the files are read.
+	Entry string `yaml:"entry,omitempty"`
+
+	// Domains are domains of the list set in the config. They may be used
+	// alongside the target or instead of it.
+	Domains []string `yaml:"domains,omitempty"`
+
+	// Sink is the address domains of the blocklist point to.
+	// If it's empty, the sink from output options is used.
+	Sink string `yaml:"sink,omitempty"`
+
+	// Format is the name of the registered parser of the list.
+	// If it's empty, the format is detected from the content.
+	Format string `yaml:"format,omitempty"`
+
+	// Subdomains is set if domains of the whitelist allow
+	// their subdomains as well.
+	Subdomains bool `yaml:"subdomains,omitempty"`
+
+	// CSV are options of the list in csv or tsv format.
+	CSV *CSV `yaml:"csv,omitempty"`
+}
+
+// CSV are options of lists in CSV format.
+type CSV struct {
+	// Column is the 1-based number or the name of the column
+	// with domains or URLs.
+	Column string `yaml:"column,omitempty"`
+	// Header is set if the first row of the list is the header.
+	Header bool `yaml:"header,omitempty"`
+}
+
+// Regex are rules of regular expressions matching domains.
+type Regex struct {
+	// Allow are expressions of domains that must not be blocked.
+	Allow []string `yaml:"allow,omitempty"`
+	// Block are expressions of domains to block. The hosts file can't block
+	// domains by patterns, so they're matched against domains of the corpus.
+	Block []string `yaml:"block,omitempty"`
+	// Corpus are paths to local files with candidate domains to block,
+	// i.e. a log of DNS queries, in any supported list format.
+	Corpus []string `yaml:"corpus,omitempty"`
+}
+
+// Host maps the domain to the IP address. Instead of a single mapping,
+// it may be a target with mappings in the hosts file format.
+type Host struct {
+	Domain string `yaml:"domain,omitempty"`
+	IP     string `yaml:"ip,omitempty"`
+	Target string `yaml:"target,omitempty"`
+}
+
+type Backup struct {
+	// Retention is the number of backup generations to keep.
+	// If it's zero, the default retention is used.
+	// The pristine copy of the hosts file is kept regardless of it.
+	Retention int `yaml:"retention"`
+}
+
+// Sort orders of blocked domains in the hosts file.
+const (
+	// SortAlphabetical sorts domains alphabetically.
+	SortAlphabetical = "alphabetical"
+	// SortDomain groups subdomains with their parent domains by sorting
+	// domains by labels in reversed order, i.e. com.example.ads.
+	SortDomain = "domain"
+)
+
+// DefaultSink is the address blocked domains point to by default.
+const DefaultSink = "127.0.0.1"
+
+type Output struct {
+	// Sort is the order of blocked domains.
+	// If it's empty, domains are sorted alphabetically.
+	Sort string `yaml:"sort"`
+
+	// Sink is the address blocked domains point to. It may be any IPv4
+	// or IPv6 address, i.e. 0.0.0.0 or a server showing "blocked" page.
+	// If it's empty, DefaultSink is used.
+	Sink string `yaml:"sink"`
+
+	// IPv6 enables writing an IPv6 entry paired with every blocked domain
+	// that points to an IPv4 sink.
+	IPv6 bool `yaml:"ipv6"`
+
+	// IPv6Sink is the address of the paired IPv6 entries.
+	// If it's empty, :: is used for 0.0.0.0 sink and ::1 for the others.
+	IPv6Sink string `yaml:"ipv6_sink,omitempty"`
+
+	// DomainsPerLine is the number of domains written on one line.
+	// If it's zero, the default of the operating system is used.
+	DomainsPerLine int `yaml:"domains_per_line,omitempty"`
 }
 
 // Load loads config file.
@@ -49,6 +170,7 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	config.Path = location
 	log.Debug().Str("location", location).Msg("config file loaded")
 
 	return config, nil
@@ -66,6 +188,7 @@ func LoadByUser(location string) (*Config, error) {
 		return nil, err
 	}
 
+	config.Path = location
 	log.Debug().Str("location", location).Msg("config file loaded")
 
 	return config, nil
@@ -193,5 +316,13 @@ func defaultConfig() *Config {
 		Whitelists: []Domainlist{
 			{Target: "https://raw.githubusercontent.com/anudeepND/whitelist/master/domains/whitelist.txt"},
 		},
+		WhitelistHostsEntries: true,
+		Backup: Backup{
+			Retention: backup.DefaultRetention,
+		},
+		Output: Output{
+			Sort: SortAlphabetical,
+			Sink: DefaultSink,
+		},
 	}
 }
@@ -0,0 +1,458 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/WIttyJudge/adless/pkg/fsutil"
+	"github.com/rs/zerolog/log"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keys of lists in the config file.
+const (
+	blocklistsKey = "blocklists"
+	whitelistsKey = "whitelists"
+	targetKey     = "target"
+	domainsKey    = "domains"
+)
+
+// defaultIndent is the indentation of the config file written by Init.
+const defaultIndent = 4
+
+var ErrOnlyBlocklist = errors.New("the domain is the last one of the only blocklist, add another blocklist to unblock it")
+
+// BlockDomains adds the domains to the inline domains of blocklists
+// and removes them from the inline domains of whitelists in the config file
+// at the path. If the path is empty, the default config file is used,
+// and it's created if it doesn't exist. Comments and formatting of the file
+// are preserved.
+func BlockDomains(path string, domains []string) error {
+	return moveDomains(path, domains, blocklistsKey, whitelistsKey)
+}
+
+// UnblockDomains adds the domains to the inline domains of whitelists
+// and removes them from the inline domains of blocklists in the config file
+// at the path, the same way as BlockDomains.
+func UnblockDomains(path string, domains []string) error {
+	return moveDomains(path, domains, whitelistsKey, blocklistsKey)
+}
+
+// moveDomains adds the domains to the inline list under the key to,
+// and removes them from the inline domains of all the lists under the key from.
+func moveDomains(path string, domains []string, to, from string) error {
+	if path == "" {
+		if err := Init(); err != nil {
+			return err
+		}
+		path = location()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	root, err := rootMapping(&document)
+	if err != nil {
+		return err
+	}
+
+	edit := newDocumentEdit(data)
+
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+
+		edit.removeInlineDomain(mappingValue(root, from), domain)
+		if edit.addInlineDomain(root, to, domain) {
+			log.Info().Str("domain", domain).Str("list", to).Msg("domain added")
+		}
+	}
+
+	if lists := mappingValue(root, blocklistsKey); from == blocklistsKey && lists != nil &&
+		lists.Kind == yaml.SequenceNode && len(lists.Content) == 0 {
+		return ErrOnlyBlocklist
+	}
+
+	output, err := edit.bytes(&document)
+	if err != nil {
+		return err
+	}
+
+	// The config is loaded back to make sure the changes keep it valid.
+	config := defaultConfig()
+	if err := yaml.Unmarshal(output, config); err != nil {
+		return err
+	}
+
+	if err := Validate(config); err != nil {
+		return err
+	}
+
+	return fsutil.WriteFile(path, output)
+}
+
+// documentEdit changes lists of the config file. Changes are made to the parsed
+// document and spliced into the original lines at the same time, so blank
+// lines, comments and their alignment are preserved. Changes that can't be
+// spliced, like the ones of flow style sequences ([a, b]), make the whole
+// document encoded again, which loses blank lines and alignment of comments.
+type documentEdit struct {
+	lines  []string
+	indent int
+	// removed are indexes of the original lines to remove.
+	removed map[int]bool
+	// inserted are lines to insert after the original line with the index.
+	// Lines inserted before the first one have index -1.
+	inserted map[int][]string
+	// tails are the places to append new items of sequences at.
+	tails map[*yaml.Node]*tail
+	// encode is set if some change can't be spliced.
+	encode bool
+}
+
+// tail is the place to append new items of a block sequence at.
+type tail struct {
+	// line is the index of the line to insert items after.
+	line int
+	// prefix is the indentation and the dash of items.
+	prefix string
+	// style is the style of scalar items.
+	style yaml.Style
+}
+
+func newDocumentEdit(data []byte) *documentEdit {
+	return &documentEdit{
+		lines:    strings.Split(string(data), "\n"),
+		indent:   detectIndent(data),
+		removed:  make(map[int]bool),
+		inserted: make(map[int][]string),
+		tails:    make(map[*yaml.Node]*tail),
+	}
+}
+
+// addInlineDomain adds the domain to the first list without target under
+// the key, appending such a list if there is none. It returns false
+// if the domain is already there.
+func (e *documentEdit) addInlineDomain(root *yaml.Node, key, domain string) bool {
+	lists := e.listsNode(root, key)
+
+	var inline *yaml.Node
+	for _, list := range lists.Content {
+		if list.Kind == yaml.MappingNode && mappingValue(list, targetKey) == nil {
+			inline = list
+			break
+		}
+	}
+
+	if inline == nil {
+		domains := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+		if listsTail := e.tail(lists); listsTail != nil {
+			e.inserted[listsTail.line] = append(e.inserted[listsTail.line], listsTail.prefix+domainsKey+":")
+			e.tails[domains] = &tail{line: listsTail.line, prefix: nestedPrefix(listsTail.prefix)}
+		}
+
+		inline = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		inline.Content = append(inline.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: domainsKey}, domains)
+		lists.Content = append(lists.Content, inline)
+	}
+
+	domains := mappingValue(inline, domainsKey)
+	if domains == nil || domains.Kind != yaml.SequenceNode {
+		// Lists without target always have domains in a valid config,
+		// so it's never the case for the config files written by hand.
+		e.encode = true
+
+		if domains == nil {
+			domains = &yaml.Node{}
+			inline.Content = append(inline.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: domainsKey}, domains)
+		}
+
+		*domains = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+
+	for _, node := range domains.Content {
+		if strings.EqualFold(node.Value, domain) {
+			return false
+		}
+	}
+
+	if domainsTail := e.tail(domains); domainsTail != nil {
+		e.inserted[domainsTail.line] = append(e.inserted[domainsTail.line], domainsTail.prefix+formatScalar(domain, domainsTail.style))
+	}
+
+	domains.Content = append(domains.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: domain})
+
+	return true
+}
+
+// listsNode returns the sequence of lists under the key, creating it
+// if it doesn't exist or is empty.
+func (e *documentEdit) listsNode(root *yaml.Node, key string) *yaml.Node {
+	keyNode, lists := mappingEntry(root, key)
+
+	switch {
+	case lists == nil:
+		lists = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, lists)
+
+		if root.Style&yaml.FlowStyle != 0 {
+			e.encode = true
+			break
+		}
+
+		end := e.end()
+		e.inserted[end] = append(e.inserted[end], key+":")
+		e.tails[lists] = &tail{line: end, prefix: strings.Repeat(" ", e.indent) + "- "}
+	case lists.Kind != yaml.SequenceNode:
+		// The key without value, like "whitelists:", gets the lists right after it.
+		if lists.Kind == yaml.ScalarNode && lists.Tag == "!!null" && lists.Value == "" && keyNode.Line > 0 {
+			prefix := strings.Repeat(" ", keyNode.Column-1+e.indent) + "- "
+			e.tails[lists] = &tail{line: keyNode.Line - 1, prefix: prefix}
+		} else {
+			e.encode = true
+		}
+
+		*lists = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+
+	return lists
+}
+
+// removeInlineDomain removes the domain from inline domains of the lists.
+// Lists left without domains and target are removed.
+func (e *documentEdit) removeInlineDomain(lists *yaml.Node, domain string) {
+	if lists == nil || lists.Kind != yaml.SequenceNode {
+		return
+	}
+
+	lists.Content = slices.DeleteFunc(lists.Content, func(list *yaml.Node) bool {
+		domains := mappingValue(list, domainsKey)
+		if domains == nil || domains.Kind != yaml.SequenceNode {
+			return false
+		}
+
+		count := len(domains.Content)
+		domains.Content = slices.DeleteFunc(domains.Content, func(node *yaml.Node) bool {
+			if !strings.EqualFold(node.Value, domain) {
+				return false
+			}
+
+			e.removeItem(domains, node)
+
+			return true
+		})
+
+		if count == len(domains.Content) {
+			return false
+		}
+
+		log.Info().Str("domain", domain).Msg("domain removed from inline list")
+
+		if len(domains.Content) > 0 || mappingValue(list, targetKey) != nil {
+			return false
+		}
+
+		e.removeItem(lists, list)
+
+		return true
+	})
+}
+
+// removeItem removes lines of the item of the sequence.
+func (e *documentEdit) removeItem(sequence, item *yaml.Node) {
+	if sequence.Style&yaml.FlowStyle != 0 || item.Line == 0 {
+		e.encode = true
+		return
+	}
+
+	for line := item.Line - 1; line < lastLine(item); line++ {
+		e.removed[line] = true
+	}
+}
+
+// tail returns the place to append new items of the sequence at,
+// or nil if they can't be spliced.
+func (e *documentEdit) tail(sequence *yaml.Node) *tail {
+	if t, ok := e.tails[sequence]; ok {
+		return t
+	}
+
+	if sequence.Style&yaml.FlowStyle != 0 || len(sequence.Content) == 0 {
+		e.encode = true
+		return nil
+	}
+
+	last := sequence.Content[len(sequence.Content)-1]
+	if last.Line == 0 || last.Line > len(e.lines) {
+		e.encode = true
+		return nil
+	}
+
+	// The prefix of items is indentation and the dash, i.e. "  - ".
+	prefix := e.lines[last.Line-1][:min(last.Column-1, len(e.lines[last.Line-1]))]
+	if strings.TrimSpace(prefix) != "-" {
+		e.encode = true
+		return nil
+	}
+
+	t := &tail{line: lastLine(last) - 1, prefix: prefix, style: last.Style}
+	e.tails[sequence] = t
+
+	return t
+}
+
+// nestedPrefix returns the prefix of items of the sequence nested
+// in the item with the prefix. They're indented by two spaces from the key,
+// the same way as the encoder does.
+func nestedPrefix(prefix string) string {
+	return strings.Repeat(" ", len(prefix)+2) + "- "
+}
+
+// end returns the index of the line to insert lines at the end of the file after.
+func (e *documentEdit) end() int {
+	end := len(e.lines) - 1
+	if e.lines[end] == "" {
+		end--
+	}
+
+	return end
+}
+
+// bytes returns the changed config file.
+func (e *documentEdit) bytes(document *yaml.Node) ([]byte, error) {
+	if e.encode {
+		log.Debug().Msg("config file can't be changed in place, encoding it again")
+		return encode(document, e.indent)
+	}
+
+	lines := make([]string, 0, len(e.lines))
+	lines = append(lines, e.inserted[-1]...)
+
+	for i, line := range e.lines {
+		if !e.removed[i] {
+			lines = append(lines, line)
+		}
+
+		lines = append(lines, e.inserted[i]...)
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func encode(document *yaml.Node, indent int) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(indent)
+
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// formatScalar returns the value written in the style.
+func formatScalar(value string, style yaml.Style) string {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		return `"` + value + `"`
+	case style&yaml.SingleQuotedStyle != 0:
+		return "'" + value + "'"
+	}
+
+	// Values like *.example.com can't be plain, so the encoder quotes them.
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return `"` + value + `"`
+	}
+
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// lastLine returns the number of the last line of the node.
+func lastLine(node *yaml.Node) int {
+	last := node.Line
+	for _, child := range node.Content {
+		last = max(last, lastLine(child))
+	}
+
+	return last
+}
+
+// rootMapping returns the top-level mapping of the document.
+// An empty document gets an empty mapping.
+func rootMapping(document *yaml.Node) (*yaml.Node, error) {
+	if document.Kind == 0 {
+		document.Kind = yaml.DocumentNode
+	}
+
+	if len(document.Content) == 0 {
+		document.Content = append(document.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("config file must be a mapping")
+	}
+
+	return root, nil
+}
+
+// mappingValue returns the value of the key in the mapping or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	_, value := mappingEntry(mapping, key)
+	return value
+}
+
+// mappingEntry returns the key node and the value of the key in the mapping
+// or nils.
+func mappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+
+	return nil, nil
+}
+
+// detectIndent returns the indentation of the config file,
+// which is the smallest indentation of its lines.
+func detectIndent(data []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if spaces := len(line) - len(trimmed); spaces > 0 && (indent == 0 || spaces < indent) {
+			indent = spaces
+		}
+	}
+
+	if indent == 0 {
+		return defaultIndent
+	}
+
+	return indent
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockDomains(t *testing.T) {
+	t.Run("adds inline list preserving comments", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		content := "# adless config\n" +
+			"blocklists:\n" +
+			"  # StevenBlack's list\n" +
+			"  - target: https://example.com/hosts # unified hosts\n" +
+			"whitelists:\n" +
+			"  - target: https://example.com/whitelist\n" +
+			"  - domains:\n" +
+			"      - ads.example.com\n" +
+			"      - cdn.example.com\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		require.NoError(t, BlockDomains(path, []string{"ads.example.com", "Tracker.example.com"}))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		expected := "# adless config\n" +
+			"blocklists:\n" +
+			"  # StevenBlack's list\n" +
+			"  - target: https://example.com/hosts # unified hosts\n" +
+			"  - domains:\n" +
+			"      - ads.example.com\n" +
+			"      - tracker.example.com\n" +
+			"whitelists:\n" +
+			"  - target: https://example.com/whitelist\n" +
+			"  - domains:\n" +
+			"      - cdn.example.com\n"
+		assert.Equal(t, expected, string(data))
+
+		config, err := read(path)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ads.example.com", "tracker.example.com"}, config.Blocklists[1].Domains)
+	})
+
+	t.Run("removes empty inline lists", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		content := "blocklists:\n" +
+			"    - target: https://example.com/hosts\n" +
+			"    - domains:\n" +
+			"        - ads.example.com\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		require.NoError(t, UnblockDomains(path, []string{"ads.example.com"}))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		expected := "blocklists:\n" +
+			"    - target: https://example.com/hosts\n" +
+			"whitelists:\n" +
+			"    - domains:\n" +
+			"        - ads.example.com\n"
+		assert.Equal(t, expected, string(data))
+	})
+
+	t.Run("preserves blank lines and aligned comments", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		content := "blocklists:\n" +
+			"  - target: \"https://example.com/hosts\"   # upstream\n" +
+			"\n" +
+			"  - domains:\n" +
+			"      - \"ads.example.com\"     # ads\n" +
+			"      - \"metrics.example.com\" # metrics\n" +
+			"\n" +
+			"whitelists:\n" +
+			"\n" +
+			"  # allowed domains\n" +
+			"  - domains:\n" +
+			"      - tracker.example.com   # broken site\n" +
+			"      - cdn.example.com\n" +
+			"\n" +
+			"sort: domain\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		require.NoError(t, BlockDomains(path, []string{"tracker.example.com"}))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		expected := "blocklists:\n" +
+			"  - target: \"https://example.com/hosts\"   # upstream\n" +
+			"\n" +
+			"  - domains:\n" +
+			"      - \"ads.example.com\"     # ads\n" +
+			"      - \"metrics.example.com\" # metrics\n" +
+			"      - \"tracker.example.com\"\n" +
+			"\n" +
+			"whitelists:\n" +
+			"\n" +
+			"  # allowed domains\n" +
+			"  - domains:\n" +
+			"      - cdn.example.com\n" +
+			"\n" +
+			"sort: domain\n"
+		assert.Equal(t, expected, string(data))
+	})
+
+	t.Run("adds lists to keys without value", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		content := "blocklists:\n" +
+			"  - domains:\n" +
+			"      - ads.example.com\n" +
+			"whitelists:\n" +
+			"\n" +
+			"sort: domain\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		require.NoError(t, UnblockDomains(path, []string{"*.youtube.com"}))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		expected := "blocklists:\n" +
+			"  - domains:\n" +
+			"      - ads.example.com\n" +
+			"whitelists:\n" +
+			"  - domains:\n" +
+			"      - '*.youtube.com'\n" +
+			"\n" +
+			"sort: domain\n"
+		assert.Equal(t, expected, string(data))
+	})
+
+	t.Run("encodes flow style lists again", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		content := "blocklists:\n" +
+			"\n" +
+			"  - domains: [ads.example.com]\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		require.NoError(t, BlockDomains(path, []string{"tracker.example.com"}))
+
+		// Blank lines are lost, as flow style lists can't be changed in place.
+		config, err := read(path)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ads.example.com", "tracker.example.com"}, config.Blocklists[0].Domains)
+	})
+
+	t.Run("keeps the config valid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yml")
+		content := "blocklists:\n" +
+			"  - domains:\n" +
+			"      - ads.example.com\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		assert.ErrorIs(t, UnblockDomains(path, []string{"ads.example.com"}), ErrOnlyBlocklist)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	})
+}
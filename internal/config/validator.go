@@ -3,10 +3,29 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/WIttyJudge/adless/internal/source"
+	"github.com/WIttyJudge/adless/pkg/parser"
 )
 
-var ErrNoBlocklistsProvided = errors.New("no blocklists provided")
+var (
+	ErrNoBlocklistsProvided   = errors.New("no blocklists provided")
+	ErrInvalidBackupRetention = errors.New("backup retention can't be negative")
+	ErrInvalidSortOrder       = errors.New("invalid sort order provided")
+	ErrInvalidSink            = errors.New("invalid sink address provided")
+	ErrInvalidIPv6Sink        = errors.New("invalid IPv6 sink address provided")
+	ErrInvalidDomainsPerLine  = errors.New("number of domains per line can't be negative")
+	ErrInvalidHost            = errors.New("invalid host provided")
+	ErrInvalidFormat          = errors.New("invalid list format provided")
+	ErrInvalidCSV             = errors.New("invalid csv options provided")
+	ErrEmptyList              = errors.New("list must have target or domains")
+	ErrInvalidRegex           = errors.New("invalid regex rule provided")
+	ErrInvalidSubdomains      = errors.New("blocklists can't block subdomains, the option is supported only by whitelists")
+)
 
 func Validate(config *Config) error {
 	if len(config.Blocklists) == 0 {
@@ -15,16 +34,128 @@ func Validate(config *Config) error {
 
 	for _, blocklist := range config.Blocklists {
 		url := blocklist.Target
-		if hasInvalidURLSymbols(url) {
+		if isInvalidTarget(url) {
 			return fmt.Errorf("invalid blocklist target provided: %s", url)
 		}
+
+		if blocklist.Sink != "" && net.ParseIP(blocklist.Sink) == nil {
+			return fmt.Errorf("%w: %s", ErrInvalidSink, blocklist.Sink)
+		}
+
+		if blocklist.Subdomains {
+			return fmt.Errorf("%w: %s", ErrInvalidSubdomains, blocklist.Target)
+		}
+	}
+
+	for _, list := range slices.Concat(config.Blocklists, config.Whitelists) {
+		if _, ok := parser.Lookup(list.Format); list.Format != "" && !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidFormat, list.Format)
+		}
+
+		if list.Target == "" && len(list.Domains) == 0 {
+			return ErrEmptyList
+		}
+
+		if err := validateCSV(list); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range slices.Concat(config.Regex.Allow, config.Regex.Block) {
+		if _, err := regexp.Compile(rule); err != nil {
+			return fmt.Errorf("%w: %q: %w", ErrInvalidRegex, rule, err)
+		}
+	}
+
+	for _, host := range config.Hosts {
+		if err := validateHost(host); err != nil {
+			return err
+		}
+	}
+
+	if config.Backup.Retention < 0 {
+		return ErrInvalidBackupRetention
+	}
+
+	switch config.Output.Sort {
+	case "", SortAlphabetical, SortDomain:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidSortOrder, config.Output.Sort)
+	}
+
+	if sink := config.Output.Sink; sink != "" && net.ParseIP(sink) == nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSink, sink)
+	}
+
+	if sink := config.Output.IPv6Sink; sink != "" && !isIPv6(sink) {
+		return fmt.Errorf("%w: %s", ErrInvalidIPv6Sink, sink)
+	}
+
+	if config.Output.DomainsPerLine < 0 {
+		return ErrInvalidDomainsPerLine
+	}
+
+	return nil
+}
+
+// validateHost checks the host has either the target,
+// or the domain with the IP address.
+func validateHost(host Host) error {
+	if host.Target != "" {
+		if host.Domain != "" || host.IP != "" {
+			return fmt.Errorf("%w: target can't be used with domain and ip: %s", ErrInvalidHost, host.Target)
+		}
+
+		if isInvalidTarget(host.Target) {
+			return fmt.Errorf("%w: invalid target: %s", ErrInvalidHost, host.Target)
+		}
+
+		return nil
+	}
+
+	if !parser.IsValidDomain(strings.ToLower(host.Domain)) {
+		return fmt.Errorf("%w: invalid domain: %q", ErrInvalidHost, host.Domain)
+	}
+
+	if net.ParseIP(host.IP) == nil {
+		return fmt.Errorf("%w: invalid ip of %s: %q", ErrInvalidHost, host.Domain, host.IP)
 	}
 
 	return nil
 }
 
+func isIPv6(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.To4() == nil
+}
+
+// isInvalidTarget checks if the target is a URL with characters not allowed
+// in URL. Local paths may contain any characters.
+func isInvalidTarget(target string) bool {
+	return source.IsURL(target) && hasInvalidURLSymbols(target)
+}
+
 // hasInvalidURLSymbols checks for characters NOT allowed in URL.
 func hasInvalidURLSymbols(url string) bool {
 	matched, _ := regexp.MatchString("[^a-zA-Z0-9:/?&%=~._()-;]", url)
 	return matched
 }
+
+// validateCSV checks options of the list in CSV format.
+func validateCSV(list Domainlist) error {
+	if list.CSV == nil {
+		return nil
+	}
+
+	switch list.Format {
+	case "", parser.FormatCSV, parser.FormatTSV:
+	default:
+		return fmt.Errorf("%w: %s list can't have csv options", ErrInvalidCSV, list.Format)
+	}
+
+	if _, err := parser.NewCSVParser(list.Format, parser.CSVOptions{Column: list.CSV.Column}); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCSV, err)
+	}
+
+	return nil
+}
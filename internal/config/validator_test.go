@@ -3,6 +3,7 @@ package config
 import (
 	"testing"
 
+	"github.com/WIttyJudge/adless/pkg/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -33,6 +34,173 @@ func TestValidate(t *testing.T) {
 
 		assert.ErrorContains(t, Validate(config), "invalid blocklist target provided")
 	})
+
+	t.Run("config has local targets", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "/etc/adless/my lists/*.txt"},
+				{Target: `C:\adless\custom.txt`},
+				{Target: "-"},
+			},
+		}
+
+		assert.NoError(t, Validate(config))
+	})
+
+	t.Run("config has invalid sort order", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "https://example.com/hosts"},
+			},
+			Output: Output{Sort: "random"},
+		}
+
+		assert.ErrorIs(t, Validate(config), ErrInvalidSortOrder)
+	})
+
+	t.Run("config has invalid sinks", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "https://example.com/hosts", Sink: "blocked.local"},
+			},
+		}
+		assert.ErrorIs(t, Validate(config), ErrInvalidSink)
+
+		config.Blocklists[0].Sink = "10.0.0.1"
+		config.Output = Output{Sink: "0.0.0.256"}
+		assert.ErrorIs(t, Validate(config), ErrInvalidSink)
+
+		config.Output = Output{Sink: "0.0.0.0", IPv6: true, IPv6Sink: "0.0.0.0"}
+		assert.ErrorIs(t, Validate(config), ErrInvalidIPv6Sink)
+
+		config.Output.IPv6Sink = "::"
+		assert.NoError(t, Validate(config))
+	})
+
+	t.Run("config has invalid list format", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "https://example.com/hosts", Format: parser.FormatDnsmasq},
+			},
+			Whitelists: []Domainlist{
+				{Target: "https://example.com/whitelist", Format: "bind"},
+			},
+		}
+
+		assert.ErrorIs(t, Validate(config), ErrInvalidFormat)
+	})
+
+	t.Run("config has invalid regex rules", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "https://example.com/hosts"},
+			},
+			Regex: Regex{
+				Allow: []string{`^ad[0-9]+\.example\.net$`},
+				Block: []string{`.*\.doubleclick\..*`, `(unclosed`},
+			},
+		}
+
+		err := Validate(config)
+		assert.ErrorIs(t, err, ErrInvalidRegex)
+		assert.ErrorContains(t, err, "(unclosed")
+
+		config.Regex.Block = config.Regex.Block[:1]
+		assert.NoError(t, Validate(config))
+	})
+
+	t.Run("list has no target and domains", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Domains: []string{"ads.example.com"}},
+			},
+			Whitelists: []Domainlist{
+				{Format: parser.FormatDomains},
+			},
+		}
+		assert.ErrorIs(t, Validate(config), ErrEmptyList)
+
+		config.Whitelists[0].Target = "https://example.com/whitelist"
+		assert.NoError(t, Validate(config))
+	})
+
+	t.Run("blocklist has subdomains option", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "https://example.com/hosts", Subdomains: true},
+			},
+		}
+		assert.ErrorIs(t, Validate(config), ErrInvalidSubdomains)
+
+		config.Blocklists[0].Subdomains = false
+		config.Whitelists = []Domainlist{{Target: "https://example.com/whitelist", Subdomains: true}}
+		assert.NoError(t, Validate(config))
+	})
+
+	t.Run("config has invalid csv options", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "https://example.com/feed.csv", CSV: &CSV{Column: "0"}},
+			},
+		}
+		assert.ErrorIs(t, Validate(config), ErrInvalidCSV)
+
+		config.Blocklists[0] = Domainlist{Target: "https://example.com/hosts", Format: parser.FormatHosts, CSV: &CSV{Column: "2"}}
+		assert.ErrorIs(t, Validate(config), ErrInvalidCSV)
+
+		config.Blocklists[0] = Domainlist{Target: "https://example.com/feed.tsv", Format: parser.FormatTSV, CSV: &CSV{Column: "url"}}
+		assert.NoError(t, Validate(config))
+	})
+
+	t.Run("config has invalid hosts", func(t *testing.T) {
+		tests := []Host{
+			{Domain: "dev.internal"},
+			{IP: "10.0.0.5"},
+			{Domain: "dev internal", IP: "10.0.0.5"},
+			{Domain: "dev.internal-", IP: "10.0.0.5"},
+			{Domain: "nas", IP: "10.0.0.5"},
+			{Domain: "dev.internal", IP: "10.0.0.256"},
+			{Domain: "dev.internal", Target: "https://example.com/hosts"},
+			{Target: "https://example.com/test page"},
+		}
+
+		for _, host := range tests {
+			config := &Config{
+				Blocklists: []Domainlist{
+					{Target: "https://example.com/hosts"},
+				},
+				Hosts: []Host{host},
+			}
+
+			assert.ErrorIs(t, Validate(config), ErrInvalidHost, host)
+		}
+	})
+
+	t.Run("config has valid hosts", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "https://example.com/hosts"},
+			},
+			Hosts: []Host{
+				{Domain: "Dev.Internal", IP: "10.0.0.5"},
+				{Domain: "www.google.com", IP: "216.239.38.120"},
+				{Target: "https://example.com/rewrites"},
+			},
+		}
+
+		assert.NoError(t, Validate(config))
+	})
+
+	t.Run("config has negative number of domains per line", func(t *testing.T) {
+		config := &Config{
+			Blocklists: []Domainlist{
+				{Target: "https://example.com/hosts"},
+			},
+			Output: Output{DomainsPerLine: -1},
+		}
+
+		assert.ErrorIs(t, Validate(config), ErrInvalidDomainsPerLine)
+	})
 }
 
 func TestHasInvalidURLSymbols(t *testing.T) {
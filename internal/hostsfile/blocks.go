@@ -0,0 +1,242 @@
+package hostsfile
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/WIttyJudge/adless/pkg/parser"
+)
+
+// ProblemKind is a kind of the problem with blocks in the hosts file.
+type ProblemKind string
+
+const (
+	ProblemDuplicatedBlock ProblemKind = "duplicated block"
+	ProblemOrphanedStart   ProblemKind = "start tag without end tag"
+	ProblemOrphanedEnd     ProblemKind = "end tag without start tag"
+	ProblemEndBeforeStart  ProblemKind = "end tag before start tag"
+)
+
+// Problem is a problem with blocks found in the hosts file.
+type Problem struct {
+	Kind ProblemKind
+	// Line is the number of the line where the problem was found.
+	Line int
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Kind)
+}
+
+// span is a part of content of the hosts file.
+type span struct {
+	// start is the offset of the first byte.
+	start int
+	// end is the offset right after the last byte.
+	end int
+	// line is the number of the first line.
+	line int
+}
+
+// blockSpan is a position of the block in content of the hosts file.
+// It starts with StartTag and ends right after EndTag. If the block isn't
+// terminated, it ends before the first line that adless doesn't write.
+type blockSpan struct {
+	span
+
+	terminated bool
+}
+
+// blocksScan is a result of looking for blocks in content.
+type blocksScan struct {
+	blocks []blockSpan
+	// orphanedEnds are lines with EndTag that don't close any block.
+	orphanedEnds []span
+}
+
+// scanBlocks finds all the blocks in content, including the ones that
+// aren't terminated by EndTag, and EndTag lines that don't belong to any block.
+func scanBlocks(content string) blocksScan {
+	var (
+		scan    blocksScan
+		current *blockSpan
+		tail    *generatedTail
+	)
+
+	// closeUnterminated closes the current block that has no EndTag.
+	closeUnterminated := func() {
+		if current != nil {
+			scan.blocks = append(scan.blocks, *current)
+			current = nil
+		}
+	}
+
+	offset, lineNumber := 0, 0
+	for offset < len(content) {
+		lineNumber++
+
+		end := lineEnd(content, offset)
+		line := strings.TrimRight(content[offset:end], "\r\n")
+
+		switch {
+		case line+"\n" == StartTag:
+			closeUnterminated()
+			current = &blockSpan{span: span{start: offset, end: end, line: lineNumber}}
+			tail = newGeneratedTail()
+		case line == EndTag && current != nil:
+			current.end = offset + len(EndTag)
+			current.terminated = true
+			scan.blocks = append(scan.blocks, *current)
+			current = nil
+		case line == EndTag:
+			scan.orphanedEnds = append(scan.orphanedEnds, span{start: offset, end: end, line: lineNumber})
+		case current != nil && current.end == offset && tail.accept(line):
+			current.end = end
+		}
+
+		offset = end
+	}
+
+	closeUnterminated()
+
+	return scan
+}
+
+// findBlocks finds all the blocks in content, including the ones that
+// aren't terminated by EndTag.
+func findBlocks(content string) []blockSpan {
+	return scanBlocks(content).blocks
+}
+
+// Diagnose returns problems with blocks in content: duplicated blocks,
+// orphaned start and end tags, and end tags placed before start tags.
+func Diagnose(content string) []Problem {
+	scan := scanBlocks(content)
+
+	var problems []Problem
+
+	terminated := 0
+	for _, block := range scan.blocks {
+		switch {
+		case !block.terminated:
+			problems = append(problems, Problem{Kind: ProblemOrphanedStart, Line: block.line})
+		case terminated > 0:
+			problems = append(problems, Problem{Kind: ProblemDuplicatedBlock, Line: block.line})
+		}
+
+		if block.terminated {
+			terminated++
+		}
+	}
+
+	for _, end := range scan.orphanedEnds {
+		kind := ProblemOrphanedEnd
+		for _, block := range scan.blocks {
+			if block.start > end.start {
+				kind = ProblemEndBeforeStart
+				break
+			}
+		}
+
+		problems = append(problems, Problem{Kind: kind, Line: end.line})
+	}
+
+	return problems
+}
+
+// Repair returns content with exactly one valid block or without blocks at all.
+// Among terminated blocks, the intact one is kept, otherwise the last one.
+// Other blocks and orphaned end tags are removed.
+func Repair(content string) string {
+	hosts := Parse(content)
+	hosts.removeBlocks(hosts.mainBlock())
+
+	return hosts.String()
+}
+
+// generatedTail tracks lines of the block that isn't terminated yet,
+// telling apart the lines written by adless from the user ones following it.
+type generatedTail struct {
+	// limit is the number of blocked and mapped domains stated
+	// in the header, -1 if unknown.
+	limit   int
+	domains map[string]struct{}
+	entries bool
+}
+
+func newGeneratedTail() *generatedTail {
+	return &generatedTail{limit: -1, domains: make(map[string]struct{})}
+}
+
+// accept checks if the line matches the format of the header or the entries
+// written by adless and, if so, adds it to the tail. Header lines are only
+// accepted before entries, and entries only until they have as many domains
+// as the header states. Without the header, only entries pointing
+// to a sink address are accepted.
+func (t *generatedTail) accept(line string) bool {
+	if line == strings.TrimSuffix(DescriptionComment, "\n") {
+		return !t.entries
+	}
+
+	if strings.HasPrefix(line, "#") {
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "# "), ": ")
+		if !ok || t.entries || !isHeaderKey(key) {
+			return false
+		}
+
+		if key == headerDomains || key == headerMappings {
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return false
+			}
+			t.limit = max(t.limit, 0) + count
+		}
+
+		return true
+	}
+
+	// Entries are written with single spaces and without comments.
+	fields := strings.Split(line, " ")
+	if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+		return false
+	}
+
+	if t.limit < 0 && !parser.IsSinkAddress(fields[0]) {
+		return false
+	}
+
+	added := make(map[string]struct{})
+	for _, domain := range fields[1:] {
+		if !parser.IsValidDomain(domain) {
+			return false
+		}
+
+		if _, ok := t.domains[domain]; !ok {
+			added[domain] = struct{}{}
+		}
+	}
+
+	if t.limit >= 0 && len(t.domains)+len(added) > t.limit {
+		return false
+	}
+
+	for domain := range added {
+		t.domains[domain] = struct{}{}
+	}
+	t.entries = true
+
+	return true
+}
+
+// isHeaderKey checks if the key is one of the header lines written by adless.
+func isHeaderKey(key string) bool {
+	switch key {
+	case headerVersion, headerGeneratedAt, headerConfig, headerDomains, headerMappings,
+		headerChecksum, headerBlocklist, headerWhitelist, headerHosts:
+		return true
+	}
+
+	return false
+}
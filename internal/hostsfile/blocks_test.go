@@ -0,0 +1,95 @@
+package hostsfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnose(t *testing.T) {
+	block := testBlock()
+
+	t.Run("no problems with a single block", func(t *testing.T) {
+		assert.Empty(t, Diagnose(testHostsContent+block+"\n"))
+	})
+
+	t.Run("finds all kinds of problems", func(t *testing.T) {
+		content := "" +
+			EndTag + "\n" +
+			testHostsContent +
+			block + "\n" +
+			block + "\n" +
+			StartTag +
+			"127.0.0.1 example.com\n" +
+			"10.0.0.1 dev.local\n" +
+			block + "\n" +
+			EndTag + "\n"
+
+		blockLines := strings.Count(block, "\n") + 1
+
+		expected := []Problem{
+			{Kind: ProblemDuplicatedBlock, Line: 4 + blockLines},
+			{Kind: ProblemOrphanedStart, Line: 4 + 2*blockLines},
+			{Kind: ProblemDuplicatedBlock, Line: 7 + 2*blockLines},
+			{Kind: ProblemEndBeforeStart, Line: 1},
+			{Kind: ProblemOrphanedEnd, Line: 7 + 3*blockLines},
+		}
+
+		assert.Equal(t, expected, Diagnose(content))
+	})
+}
+
+func TestRepair(t *testing.T) {
+	block := testBlock()
+
+	t.Run("keeps the intact block", func(t *testing.T) {
+		modified := strings.Replace(block, "example.com", "example.net", 1)
+		content := testHostsContent + block + "\n" + modified + "\n"
+
+		assert.Equal(t, testHostsContent+block+"\n", Repair(content))
+	})
+
+	t.Run("keeps the last block if none is intact", func(t *testing.T) {
+		modified := strings.Replace(block, "example.com", "example.net", 1)
+		content := testHostsContent + modified + "\n" + modified + "\n"
+
+		repaired := Repair(content)
+
+		assert.Empty(t, Diagnose(repaired))
+		assert.Equal(t, testHostsContent+modified+"\n", repaired)
+	})
+
+	t.Run("removes orphaned tags", func(t *testing.T) {
+		content := EndTag + "\n" + testHostsContent + StartTag + "127.0.0.1 example.com\n"
+
+		assert.Equal(t, testHostsContent, Repair(content))
+	})
+
+	t.Run("keeps user lines following a truncated block", func(t *testing.T) {
+		truncated := strings.TrimSuffix(block, "\n127.0.0.1 example.org\n"+EndTag) + "\n"
+		user := "\n# my hosts\n127.0.0.1 myhost\n10.0.0.1 dev.local\n"
+
+		assert.Equal(t, testHostsContent+user, Repair(testHostsContent+truncated+user))
+	})
+}
+
+func TestRemoveBlocks(t *testing.T) {
+	block := testBlock()
+
+	t.Run("removes duplicated blocks", func(t *testing.T) {
+		content := testHostsContent + block + "\n" + block
+
+		hosts := Parse(content)
+		hosts.RemoveBlocks()
+		assert.Equal(t, testHostsContent, hosts.String())
+	})
+
+	t.Run("removes not terminated block keeping user entries", func(t *testing.T) {
+		content := testHostsContent + strings.TrimSuffix(block, EndTag) + "10.0.0.1 dev.local\n"
+
+		hosts := Parse(content)
+		hosts.RemoveBlocks()
+		assert.Equal(t, testHostsContent+"10.0.0.1 dev.local\n", hosts.String())
+	})
+}
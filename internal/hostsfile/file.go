@@ -6,8 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
+	"github.com/WIttyJudge/adless/internal/backup"
 	"github.com/WIttyJudge/adless/pkg/fsutil"
 	"github.com/rs/zerolog/log"
 )
@@ -18,10 +20,11 @@ const (
 	DescriptionComment = "# Generated by the adless CLI tool. DO NOT EDIT!\n"
 )
 
-var (
-	ErrStartTagNotFound = errors.New("start tag not found")
-	ErrEndTagNotFound   = errors.New("end tag not found")
-)
+// legacyBackupExtension is the extension of the single backup file
+// kept next to the hosts file by older versions of adless.
+const legacyBackupExtension = ".backup"
+
+var ErrStartTagNotFound = errors.New("start tag not found")
 
 type Status int
 
@@ -30,69 +33,181 @@ const (
 	Disabled
 )
 
+func (s Status) String() string {
+	if s == Enabled {
+		return "enabled"
+	}
+
+	return "disabled"
+}
+
 // File is a hosts file.
 type File struct {
-	file *os.File
-
-	fileLocation   string
-	backupLocation string
+	fileLocation string
+	backups      *backup.Store
 }
 
-// New returns a new hostsfile wrapper.
-func New() (*File, error) {
-	location := location()
-	backupLocation := location + ".backup"
+// New returns a new hostsfile wrapper around the hosts file at location.
+// If location is empty, the hosts file of the operating system is used.
+// backupRetention is the number of backup generations to keep, zero means
+// the default retention.
+func New(location string, backupRetention int) (*File, error) {
+	if location == "" {
+		location = Location()
+	}
 
-	osFile, err := os.OpenFile(location, os.O_WRONLY|os.O_APPEND, 0o644)
+	if backupRetention == 0 {
+		backupRetention = backup.DefaultRetention
+	}
+
+	// Makes sure the file exists and can be modified before doing anything.
+	osFile, err := os.OpenFile(location, os.O_WRONLY, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
+	osFile.Close()
 
 	file := &File{
-		file:           osFile,
-		fileLocation:   location,
-		backupLocation: backupLocation,
+		fileLocation: location,
+		backups:      backup.NewStore(backup.Dir(location), backupRetention),
+	}
+
+	if err := file.importLegacyBackup(); err != nil {
+		log.Warn().Err(err).Msg("failed to import legacy backup")
 	}
 
 	return file, nil
 }
 
-// Backup creates a copy of hosts file with .backup suffix.
-func (f *File) Backup() error {
-	log.Debug().Str("location", f.backupLocation).Msg("backup hosts file..")
-	return fsutil.CopyFile(f.fileLocation, f.backupLocation)
+// importLegacyBackup imports the backup file kept by older versions
+// of adless as the first backup generation, so it isn't lost after
+// upgrading. It's imported only while there are no other generations.
+func (f *File) importLegacyBackup() error {
+	location := f.fileLocation + legacyBackupExtension
+
+	info, err := os.Stat(location)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	backups, err := f.backups.List()
+	if err != nil {
+		return err
+	}
+
+	for _, generation := range backups {
+		if generation.ID != backup.PristineID {
+			return nil
+		}
+	}
+
+	content, err := os.ReadFile(location)
+	if err != nil {
+		return err
+	}
+
+	imported, err := f.backups.Import(content, "legacy", info.ModTime())
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("id", imported.ID).Str("location", location).Msg("legacy backup imported")
+
+	return nil
 }
 
-// Restore restores the original hosts file from its backup.
-func (f *File) Restore() error {
-	return fsutil.CopyFile(f.backupLocation, f.fileLocation)
+// Backups returns the store with backups of the hosts file.
+func (f *File) Backups() *backup.Store {
+	return f.backups
 }
 
-// Write writes content to file
-// It appends content to the end of file intead of rewriting it.
-func (f *File) Write(content string) error {
-	if _, err := f.file.WriteString(content); err != nil {
+// Backup saves a new backup generation of the hosts file made by the command.
+// The very first time it also takes the pristine snapshot of the hosts file
+// without domains blocking.
+func (f *File) Backup(command string) error {
+	log.Debug().Msg("backup hosts file..")
+
+	content, err := os.ReadFile(f.fileLocation)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	if !f.backups.HasPristine() {
+		pristine, err := RemoveBlock(string(content))
+		if errors.Is(err, ErrStartTagNotFound) {
+			pristine = string(content)
+		} else if err != nil {
+			return err
+		}
+
+		if err := f.backups.SavePristine([]byte(pristine)); err != nil {
+			return err
+		}
+	}
+
+	_, err = f.backups.Save(content, command)
+
+	return err
 }
 
-// Rewrite rewrites the entire file to the content provided.
-// os.Create method truncated file completely, then WriteString writes new
-// content.
-func (f *File) Rewrite(content string) error {
-	// If the file already exists, if will be truncated.
-	newFile, err := os.Create(f.fileLocation)
+// Restore restores the hosts file from the backup with the ID.
+// If the ID is empty, the latest backup generation is used.
+// The current hosts file is backed up before, so restoring can be undone
+// by restoring that backup explicitly. It's never used as the latest one.
+func (f *File) Restore(id string) error {
+	content, err := f.BackupContent(id)
 	if err != nil {
 		return err
 	}
 
-	if _, err := newFile.WriteString(content); err != nil {
+	if err := f.Backup(backup.RestoreCommand); err != nil {
 		return err
 	}
 
-	return nil
+	return f.Rewrite(content)
+}
+
+// BackupContent returns content of the backup with the ID.
+// If the ID is empty, the latest backup generation is used.
+func (f *File) BackupContent(id string) (string, error) {
+	if id == "" {
+		latest, err := f.backups.Latest()
+		if err != nil {
+			return "", err
+		}
+
+		id = latest.ID
+	}
+
+	content, err := f.backups.Content(id)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// Rewrite rewrites the entire file to the content provided.
+// The file is replaced atomically, so it's never left half-written.
+func (f *File) Rewrite(content string) error {
+	return fsutil.WriteFile(f.fileLocation, []byte(content))
+}
+
+// Location returns the path to the hosts file.
+func (f *File) Location() string {
+	return f.fileLocation
+}
+
+// Content returns content of the file by its location.
+func (f *File) Content() (string, error) {
+	content, err := os.ReadFile(f.fileLocation)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
 }
 
 // Read returns content of the file by its location.
@@ -104,42 +219,101 @@ func (f *File) Read() string {
 // Status checks if hosts file already has domains that are being blocked.
 // If StartTag exists, it means domains blocking enabled.
 func (f *File) Status() Status {
-	content := f.Read()
-
-	if strings.Contains(content, StartTag) {
+	if Parse(f.Read()).HasBlock() {
 		return Enabled
 	}
 
 	return Disabled
 }
 
-// RemoveDomainsBlocking removes domains located between StartTag and EndTag
-// that were parsed from blocklists.
-func (f *File) RemoveDomainsBlocking() error {
-	content := f.Read()
+// AddBlock returns content with the block of blocked domains appended
+// to its end. If content already has the block, it's replaced in place.
+func AddBlock(content, block string) string {
+	hosts := Parse(content)
+	hosts.SetBlock(block)
 
-	startIndex := strings.Index(content, StartTag)
-	if startIndex == -1 {
-		return ErrStartTagNotFound
+	return hosts.String()
+}
+
+// RemoveBlock returns content without domains located between
+// StartTag and EndTag. Broken blocks are removed as well: duplicated ones,
+// the ones without EndTag and orphaned end tags.
+func RemoveBlock(content string) (string, error) {
+	hosts := Parse(content)
+	if !hosts.HasBlock() {
+		return "", ErrStartTagNotFound
 	}
 
-	endIndex := strings.Index(content, EndTag)
-	if endIndex == -1 {
-		return ErrEndTagNotFound
+	hosts.RemoveBlocks()
+
+	return hosts.String(), nil
+}
+
+// Domains returns unique domains blocked in the block of content.
+// A domain paired with IPv6 entry is returned once.
+func Domains(content string) []string {
+	return blockDomains(Block(content))
+}
+
+// BlockedDomains returns unique domains blocked by the main block of content,
+// without the ones mapped to specific addresses by hosts. The main block is
+// the one kept by Repair, or the first one if none of the blocks
+// is terminated. It also returns the number of the line the block starts at,
+// or zero if there is no block.
+func BlockedDomains(content string) ([]string, int) {
+	hosts := Parse(content)
+
+	main := hosts.mainBlock()
+	if main == -1 {
+		main = slices.IndexFunc(hosts.Lines, Line.isBlock)
+	}
+	if main == -1 {
+		return nil, 0
 	}
-	endIndex += len(EndTag)
 
-	resultContent := content[:startIndex] + content[endIndex:]
+	lineNumber := 1
+	for _, line := range hosts.Lines[:main] {
+		lineNumber += strings.Count(line.Text, "\n") + 1
+	}
 
-	if err := f.Rewrite(resultContent); err != nil {
-		return err
+	block := strings.ReplaceAll(hosts.Lines[main].Text, "\r\n", "\n")
+	domains := blockDomains(block)
+
+	// Mapped domains are written after the blocked ones.
+	if header, err := parseHeader(block); err == nil {
+		domains = domains[:max(len(domains)-header.MappingsCount, 0)]
 	}
 
-	return nil
+	return domains, lineNumber
+}
+
+// blockDomains returns unique domains of the entries of the block.
+func blockDomains(block string) []string {
+	if block == "" {
+		return nil
+	}
+
+	var domains []string
+	seen := make(map[string]struct{})
+	for _, line := range strings.Split(block, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		for _, domain := range fields[1:] {
+			if _, ok := seen[domain]; !ok {
+				seen[domain] = struct{}{}
+				domains = append(domains, domain)
+			}
+		}
+	}
+
+	return domains
 }
 
-// location returns the path to the hosts file based on the operating system.
-func location() string {
+// Location returns the path to the hosts file based on the operating system.
+func Location() string {
 	const unixHostsFile = "/etc/hosts"
 	const windowsHostsFile = `C:\Windows\System32\drivers\etc\hosts`
 
@@ -0,0 +1,225 @@
+package hostsfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WIttyJudge/adless/internal/backup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHostsContent = "127.0.0.1 localhost\n::1 localhost\n"
+
+func newTestFile(t *testing.T, content string) *File {
+	t.Helper()
+
+	td := t.TempDir()
+	t.Setenv("ADLESS_STATE_DIR", filepath.Join(td, "state"))
+
+	location := filepath.Join(td, "hosts")
+	require.NoError(t, os.WriteFile(location, []byte(content), 0o644))
+
+	hosts, err := New(location, 0)
+	require.NoError(t, err)
+
+	return hosts
+}
+
+func TestNew(t *testing.T) {
+	t.Run("opens hosts file at custom location", func(t *testing.T) {
+		hosts := newTestFile(t, testHostsContent)
+
+		assert.Equal(t, testHostsContent, hosts.Read())
+		assert.Equal(t, Disabled, hosts.Status())
+	})
+
+	t.Run("returns error if hosts file doesn't exist", func(t *testing.T) {
+		hosts, err := New(filepath.Join(t.TempDir(), "hosts"), 0)
+
+		assert.Nil(t, hosts)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("uses system hosts file if location is empty", func(t *testing.T) {
+		// New imports the legacy backup into the state directory.
+		t.Setenv("ADLESS_STATE_DIR", t.TempDir())
+
+		hosts, err := New("", 0)
+		if err != nil {
+			t.Skip("system hosts file isn't writable")
+		}
+
+		assert.Equal(t, Location(), hosts.Location())
+	})
+}
+
+func TestRemoveBlock(t *testing.T) {
+	t.Run("removes block written before", func(t *testing.T) {
+		hosts := newTestFile(t, testHostsContent)
+
+		block := StartTag + DescriptionComment + "127.0.0.1 example.com\n" + EndTag
+		require.NoError(t, hosts.Rewrite(AddBlock(hosts.Read(), block)))
+		assert.Equal(t, Enabled, hosts.Status())
+
+		content, err := RemoveBlock(hosts.Read())
+		require.NoError(t, err)
+		require.NoError(t, hosts.Rewrite(content))
+		assert.Equal(t, Disabled, hosts.Status())
+		assert.Equal(t, testHostsContent, hosts.Read())
+	})
+
+	t.Run("returns error if there is no block", func(t *testing.T) {
+		_, err := RemoveBlock(testHostsContent)
+		assert.ErrorIs(t, err, ErrStartTagNotFound)
+	})
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	t.Run("restores the latest backup", func(t *testing.T) {
+		hosts := newTestFile(t, testHostsContent)
+
+		require.NoError(t, hosts.Backup("test"))
+		require.NoError(t, hosts.Rewrite("changed"))
+		require.NoError(t, hosts.Restore(""))
+
+		assert.Equal(t, testHostsContent, hosts.Read())
+	})
+
+	t.Run("restores pristine copy without domains blocking", func(t *testing.T) {
+		block := StartTag + DescriptionComment + "127.0.0.1 example.com\n" + EndTag
+		hosts := newTestFile(t, testHostsContent+block)
+
+		require.NoError(t, hosts.Backup("enable"))
+		require.NoError(t, hosts.Rewrite("changed"))
+		require.NoError(t, hosts.Backup("update"))
+		require.NoError(t, hosts.Restore(backup.PristineID))
+
+		assert.Equal(t, testHostsContent, hosts.Read())
+	})
+
+	t.Run("restoring twice restores the same backup", func(t *testing.T) {
+		hosts := newTestFile(t, testHostsContent)
+
+		require.NoError(t, hosts.Backup("update"))
+		require.NoError(t, hosts.Rewrite("changed"))
+		require.NoError(t, hosts.Restore(""))
+		require.NoError(t, hosts.Restore(""))
+
+		assert.Equal(t, testHostsContent, hosts.Read())
+	})
+
+	t.Run("returns error if there are no backups", func(t *testing.T) {
+		hosts := newTestFile(t, testHostsContent)
+
+		assert.ErrorIs(t, hosts.Restore(""), backup.ErrNotFound)
+	})
+
+	t.Run("imports legacy backup", func(t *testing.T) {
+		td := t.TempDir()
+		t.Setenv("ADLESS_STATE_DIR", filepath.Join(td, "state"))
+
+		location := filepath.Join(td, "hosts")
+		require.NoError(t, os.WriteFile(location, []byte("changed"), 0o644))
+		require.NoError(t, os.WriteFile(location+legacyBackupExtension, []byte(testHostsContent), 0o644))
+
+		hosts, err := New(location, 0)
+		require.NoError(t, err)
+
+		backups, err := hosts.Backups().List()
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		assert.Equal(t, "legacy", backups[0].Command)
+
+		// It's imported only once.
+		hosts, err = New(location, 0)
+		require.NoError(t, err)
+
+		backups, err = hosts.Backups().List()
+		require.NoError(t, err)
+		assert.Len(t, backups, 1)
+
+		require.NoError(t, hosts.Restore(""))
+		assert.Equal(t, testHostsContent, hosts.Read())
+	})
+}
+
+func TestAddBlock(t *testing.T) {
+	block := StartTag + DescriptionComment + "127.0.0.1 example.com\n" + EndTag
+
+	t.Run("appends block", func(t *testing.T) {
+		content := AddBlock(testHostsContent, block)
+
+		assert.Equal(t, testHostsContent+block+"\n", content)
+	})
+
+	t.Run("replaces existing block", func(t *testing.T) {
+		newBlock := StartTag + DescriptionComment + "127.0.0.1 example.org\n" + EndTag
+
+		content := AddBlock(testHostsContent+block, newBlock)
+
+		assert.Equal(t, testHostsContent+newBlock+"\n", content)
+	})
+}
+
+func TestDomains(t *testing.T) {
+	t.Run("returns domains from block", func(t *testing.T) {
+		block := StartTag + DescriptionComment + "127.0.0.1 example.com\n127.0.0.1 example.org\n" + EndTag
+
+		assert.Equal(t, []string{"example.com", "example.org"}, Domains(testHostsContent+block))
+	})
+
+	t.Run("returns domains paired with IPv6 entries once", func(t *testing.T) {
+		block := StartTag + DescriptionComment + "0.0.0.0 example.com\n:: example.com\n" + EndTag
+
+		assert.Equal(t, []string{"example.com"}, Domains(block))
+	})
+
+	t.Run("ignores entries outside of block", func(t *testing.T) {
+		assert.Empty(t, Domains(testHostsContent))
+	})
+}
+
+func TestBlockedDomains(t *testing.T) {
+	t.Run("skips mapped domains", func(t *testing.T) {
+		result := Result{
+			startTag:           StartTag,
+			endTag:             EndTag,
+			descriptionComment: DescriptionComment,
+			domains: map[string]LineContent{
+				"z.example.com": {ipAddress: "0.0.0.0", domainName: "z.example.com"},
+			},
+			hosts: map[string]LineContent{
+				"a.example.com": {ipAddress: "10.0.0.5", domainName: "a.example.com"},
+			},
+		}
+
+		domains, line := BlockedDomains(testHostsContent + result.FormatToHostsfile())
+
+		assert.Equal(t, []string{"z.example.com"}, domains)
+		assert.Equal(t, 3, line)
+	})
+
+	t.Run("uses the block kept by repair", func(t *testing.T) {
+		block := testBlock()
+		modified := strings.Replace(block, "example.org", "example.net", 1)
+		content := testHostsContent + modified + "\n" + block + "\n"
+
+		domains, line := BlockedDomains(content)
+
+		assert.Equal(t, []string{"example.com", "example.org"}, domains)
+		assert.Equal(t, 3+strings.Count(modified, "\n")+1, line)
+
+		repairedDomains, _ := BlockedDomains(Repair(content))
+		assert.Equal(t, domains, repairedDomains)
+	})
+
+	t.Run("returns nothing without block", func(t *testing.T) {
+		domains, line := BlockedDomains(testHostsContent)
+
+		assert.Empty(t, domains)
+		assert.Zero(t, line)
+	})
+}
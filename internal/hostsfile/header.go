@@ -0,0 +1,282 @@
+package hostsfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Keys of the header lines written after DescriptionComment.
+const (
+	headerVersion     = "adless-version"
+	headerGeneratedAt = "generated-at"
+	headerConfig      = "config"
+	headerDomains     = "domains"
+	headerMappings    = "mappings"
+	headerChecksum    = "checksum"
+	headerBlocklist   = "blocklist"
+	headerWhitelist   = "whitelist"
+	headerHosts       = "hosts"
+)
+
+// Header describes how the block of blocked domains was generated.
+// It's written at the beginning of the block as comments, so it can be
+// parsed back from the hosts file.
+//
+// Example:
+//
+//	# adless-version: v1.0.0
+//	# generated-at: 2024-10-17T10:15:00Z
+//	# config: /home/user/.config/adless/config.yml
+//	# domains: 1028
+//	# mappings: 2
+//	# checksum: sha256:2c26b46...
+//	# blocklist: https://example.com/hosts domains=1024 sha256=9f86d08...
+//	# whitelist: https://example.com/whitelist.txt domains=12 sha256=60303ae...
+//	# hosts: https://example.com/rewrites.txt domains=2 sha256=fd61a03...
+type Header struct {
+	Version     string    `json:"version,omitempty"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	ConfigPath  string    `json:"configPath,omitempty"`
+	// DomainsCount is the number of blocked domains in the block.
+	DomainsCount int `json:"domainsCount"`
+	// MappingsCount is the number of domains in the block mapped
+	// to specific addresses by hosts.
+	MappingsCount int `json:"mappingsCount,omitempty"`
+	// Checksum is a checksum of the block entries following the header.
+	Checksum   string   `json:"checksum,omitempty"`
+	Blocklists []Source `json:"blocklists"`
+	Whitelists []Source `json:"whitelists"`
+	// Hosts are targets with domains mapped to specific addresses.
+	Hosts []Source `json:"hosts,omitempty"`
+}
+
+// Source describes a list that contributed to the block.
+type Source struct {
+	Target       string `json:"target"`
+	DomainsCount int    `json:"domainsCount"`
+	// Checksum is a checksum of the list content.
+	Checksum string `json:"checksum,omitempty"`
+	// Error is set if the list failed to be processed.
+	Error string `json:"error,omitempty"`
+}
+
+// Format returns the header as comment lines.
+func (h Header) Format() string {
+	var builder strings.Builder
+
+	if h.Version != "" {
+		writeHeaderLine(&builder, headerVersion, h.Version)
+	}
+
+	writeHeaderLine(&builder, headerGeneratedAt, h.GeneratedAt.UTC().Format(time.RFC3339))
+
+	if h.ConfigPath != "" {
+		writeHeaderLine(&builder, headerConfig, h.ConfigPath)
+	}
+
+	writeHeaderLine(&builder, headerDomains, strconv.Itoa(h.DomainsCount))
+
+	if h.MappingsCount > 0 {
+		writeHeaderLine(&builder, headerMappings, strconv.Itoa(h.MappingsCount))
+	}
+
+	if h.Checksum != "" {
+		writeHeaderLine(&builder, headerChecksum, h.Checksum)
+	}
+
+	for _, source := range h.Blocklists {
+		writeHeaderLine(&builder, headerBlocklist, source.format())
+	}
+
+	for _, source := range h.Whitelists {
+		writeHeaderLine(&builder, headerWhitelist, source.format())
+	}
+
+	for _, source := range h.Hosts {
+		writeHeaderLine(&builder, headerHosts, source.format())
+	}
+
+	return builder.String()
+}
+
+// ParseHeader parses the header of the block from content of the hosts file.
+// Header lines are the comments following StartTag.
+func ParseHeader(content string) (Header, error) {
+	block := Block(content)
+	if block == "" {
+		return Header{}, ErrStartTagNotFound
+	}
+
+	return parseHeader(block)
+}
+
+// parseHeader parses the header of the block.
+func parseHeader(block string) (Header, error) {
+	var header Header
+
+	lines := strings.Split(strings.TrimPrefix(block, StartTag), "\n")
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "#") || line == EndTag {
+			break
+		}
+
+		key, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "#")), ": ")
+		if !ok {
+			continue
+		}
+
+		if err := header.set(key, value); err != nil {
+			return Header{}, fmt.Errorf("invalid header %q: %w", line, err)
+		}
+	}
+
+	return header, nil
+}
+
+func (h *Header) set(key, value string) error {
+	switch key {
+	case headerVersion:
+		h.Version = value
+	case headerGeneratedAt:
+		generatedAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		h.GeneratedAt = generatedAt
+	case headerConfig:
+		h.ConfigPath = value
+	case headerDomains:
+		domainsCount, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		h.DomainsCount = domainsCount
+	case headerMappings:
+		mappingsCount, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		h.MappingsCount = mappingsCount
+	case headerChecksum:
+		h.Checksum = value
+	case headerBlocklist:
+		h.Blocklists = append(h.Blocklists, parseSource(value))
+	case headerWhitelist:
+		h.Whitelists = append(h.Whitelists, parseSource(value))
+	case headerHosts:
+		h.Hosts = append(h.Hosts, parseSource(value))
+	}
+
+	return nil
+}
+
+// format returns the source as the target followed by its attributes.
+// The target with spaces is quoted, so it can be parsed back.
+func (s Source) format() string {
+	target := s.Target
+	if strings.IndexFunc(target, unicode.IsSpace) >= 0 || strings.HasPrefix(target, `"`) {
+		target = strconv.Quote(target)
+	}
+
+	if s.Error != "" {
+		return target + " failed"
+	}
+
+	attributes := []string{target, "domains=" + strconv.Itoa(s.DomainsCount)}
+	if algorithm, hash, ok := strings.Cut(s.Checksum, ":"); ok {
+		attributes = append(attributes, algorithm+"="+hash)
+	}
+
+	return strings.Join(attributes, " ")
+}
+
+func parseSource(value string) Source {
+	var source Source
+
+	if quoted, err := strconv.QuotedPrefix(value); err == nil {
+		source.Target, _ = strconv.Unquote(quoted)
+		value = strings.TrimPrefix(value, quoted)
+	} else {
+		source.Target, value, _ = strings.Cut(value, " ")
+	}
+
+	for _, field := range strings.Fields(value) {
+		if field == "failed" {
+			source.Error = "failed"
+			continue
+		}
+
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "domains":
+			source.DomainsCount, _ = strconv.Atoi(value)
+		case "sha256":
+			source.Checksum = key + ":" + value
+		}
+	}
+
+	return source
+}
+
+// SameContent checks if contents of the hosts file are the same apart from
+// the time the blocks were generated at.
+func SameContent(a, b string) bool {
+	return withoutGeneratedAt(a) == withoutGeneratedAt(b)
+}
+
+// withoutGeneratedAt returns content without generated-at header lines
+// of the blocks.
+func withoutGeneratedAt(content string) string {
+	var builder strings.Builder
+
+	offset := 0
+	for _, block := range findBlocks(content) {
+		builder.WriteString(content[offset:block.start])
+
+		for _, line := range strings.SplitAfter(content[block.start:block.end], "\n") {
+			if !strings.HasPrefix(line, "# "+headerGeneratedAt+": ") {
+				builder.WriteString(line)
+			}
+		}
+
+		offset = block.end
+	}
+	builder.WriteString(content[offset:])
+
+	return builder.String()
+}
+
+func newSources(results []TargetResult) []Source {
+	sources := make([]Source, 0, len(results))
+	for _, result := range results {
+		// Hosts set in the config have no target.
+		if result.Target == "" {
+			continue
+		}
+
+		source := Source{
+			Target:       result.Target,
+			DomainsCount: result.DomainsCount,
+			Checksum:     result.Checksum,
+		}
+
+		if result.Err != nil {
+			source.Error = result.Err.Error()
+		}
+
+		sources = append(sources, source)
+	}
+
+	return sources
+}
+
+func writeHeaderLine(builder *strings.Builder, key, value string) {
+	builder.WriteString("# " + key + ": " + value + "\n")
+}
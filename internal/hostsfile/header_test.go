@@ -0,0 +1,100 @@
+package hostsfile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeader(t *testing.T) {
+	t.Run("parses formatted header back", func(t *testing.T) {
+		header := Header{
+			Version:     "v1.0.0",
+			GeneratedAt: time.Date(2024, 10, 17, 10, 15, 0, 0, time.UTC),
+			ConfigPath:  "/home/user/.config/adless/config.yml",
+			Blocklists: []Source{
+				{Target: "https://example.com/hosts", DomainsCount: 1024, Checksum: "sha256:9f86d08"},
+				{Target: "https://example.com/broken", Error: "failed"},
+			},
+			Whitelists: []Source{
+				{Target: "https://example.com/whitelist.txt", DomainsCount: 12, Checksum: "sha256:60303ae"},
+			},
+			Hosts: []Source{
+				{Target: "https://example.com/rewrites.txt", DomainsCount: 2, Checksum: "sha256:fd61a03"},
+			},
+		}
+
+		content := testHostsContent + StartTag + DescriptionComment + header.Format() +
+			"127.0.0.1 example.com\n" + EndTag
+
+		parsed, err := ParseHeader(content)
+		require.NoError(t, err)
+
+		assert.Equal(t, header, parsed)
+	})
+
+	t.Run("parses targets with spaces back", func(t *testing.T) {
+		header := Header{
+			Blocklists: []Source{
+				{Target: "my lists/*.txt", DomainsCount: 3, Checksum: "sha256:9f86d08"},
+				{Target: `C:\My Lists\hosts.txt`, Error: "failed"},
+			},
+		}
+
+		content := StartTag + DescriptionComment + header.Format() + "127.0.0.1 example.com\n" + EndTag
+		assert.Contains(t, content, `# blocklist: "my lists/*.txt" domains=3 sha256=9f86d08`)
+
+		parsed, err := ParseHeader(content)
+		require.NoError(t, err)
+
+		assert.Equal(t, header.Blocklists, parsed.Blocklists)
+	})
+
+	t.Run("returns empty header for block without it", func(t *testing.T) {
+		content := StartTag + DescriptionComment + "127.0.0.1 example.com\n" + EndTag
+
+		parsed, err := ParseHeader(content)
+		require.NoError(t, err)
+
+		assert.Equal(t, Header{}, parsed)
+	})
+
+	t.Run("returns error if there is no block", func(t *testing.T) {
+		_, err := ParseHeader(testHostsContent)
+
+		assert.ErrorIs(t, err, ErrStartTagNotFound)
+	})
+}
+
+func TestSameContent(t *testing.T) {
+	newBlock := func(generatedAt time.Time, domain string) string {
+		header := Header{GeneratedAt: generatedAt, DomainsCount: 1}
+		return StartTag + DescriptionComment + header.Format() + "127.0.0.1 " + domain + "\n" + EndTag
+	}
+
+	earlier := time.Date(2024, 10, 17, 10, 15, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	t.Run("blocks generated at different time", func(t *testing.T) {
+		assert.True(t, SameContent(
+			testHostsContent+newBlock(earlier, "example.com"),
+			testHostsContent+newBlock(later, "example.com"),
+		))
+	})
+
+	t.Run("blocks with different domains", func(t *testing.T) {
+		assert.False(t, SameContent(
+			testHostsContent+newBlock(earlier, "example.com"),
+			testHostsContent+newBlock(earlier, "example.org"),
+		))
+	})
+
+	t.Run("different content outside of the block", func(t *testing.T) {
+		assert.False(t, SameContent(
+			testHostsContent+newBlock(earlier, "example.com"),
+			newBlock(earlier, "example.com"),
+		))
+	})
+}
@@ -0,0 +1,312 @@
+package hostsfile
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// LineKind is a kind of the line in the hosts file.
+type LineKind int
+
+const (
+	// LineBlank is an empty line or a line of whitespaces.
+	LineBlank LineKind = iota
+	// LineComment is a line starting with #.
+	LineComment
+	// LineEntry is an IP address followed by hostnames.
+	LineEntry
+	// LineInvalid is a line that can't be parsed as an entry.
+	LineInvalid
+	// LineBlock is a block of blocked domains, or its orphaned end tag.
+	// It spans several lines of the hosts file.
+	LineBlock
+)
+
+// Line is a line of the hosts file.
+type Line struct {
+	Kind LineKind
+	// Text is the line as it's written in the file without the line ending.
+	// For LineBlock, it's the whole block.
+	Text string
+	// Ending is the line ending: "\n", "\r\n" or an empty string
+	// for the last line of the file without it.
+	Ending string
+
+	// IP is the address of LineEntry.
+	IP string
+	// Hostnames are the canonical hostname of LineEntry followed by its aliases.
+	Hostnames []string
+	// Comment is the text after # of LineComment and LineEntry.
+	Comment string
+
+	// terminated is set for LineBlock ending with EndTag.
+	terminated bool
+}
+
+// Hostname returns the canonical hostname of the entry.
+func (l Line) Hostname() string {
+	if len(l.Hostnames) == 0 {
+		return ""
+	}
+
+	return l.Hostnames[0]
+}
+
+// Aliases returns aliases of the canonical hostname of the entry.
+func (l Line) Aliases() []string {
+	if len(l.Hostnames) < 2 {
+		return nil
+	}
+
+	return l.Hostnames[1:]
+}
+
+// isBlock checks if the line is a block starting with StartTag,
+// not an orphaned end tag.
+func (l Line) isBlock() bool {
+	return l.Kind == LineBlock && strings.HasPrefix(l.Text, strings.TrimSuffix(StartTag, "\n"))
+}
+
+// Hosts is a parsed hosts file. Converting it back to string gives exactly
+// the same content, so changing the block keeps user entries, comments,
+// whitespaces and line endings untouched.
+type Hosts struct {
+	Lines []Line
+}
+
+// Parse parses content of the hosts file.
+func Parse(content string) *Hosts {
+	scan := scanBlocks(content)
+
+	managed := make([]blockSpan, 0, len(scan.blocks)+len(scan.orphanedEnds))
+	managed = append(managed, scan.blocks...)
+	for _, end := range scan.orphanedEnds {
+		managed = append(managed, blockSpan{span: end})
+	}
+
+	sort.Slice(managed, func(i, j int) bool {
+		return managed[i].start < managed[j].start
+	})
+
+	hosts := &Hosts{}
+
+	offset := 0
+	for offset < len(content) {
+		if len(managed) > 0 && managed[0].start == offset {
+			// The block takes every line it touches.
+			block := managed[0]
+			managed = managed[1:]
+
+			end := offset
+			for end < block.end {
+				end = lineEnd(content, end)
+			}
+
+			text, ending := splitEnding(content[offset:end])
+			hosts.Lines = append(hosts.Lines, Line{
+				Kind:       LineBlock,
+				Text:       text,
+				Ending:     ending,
+				terminated: block.terminated,
+			})
+
+			offset = end
+			continue
+		}
+
+		end := lineEnd(content, offset)
+		hosts.Lines = append(hosts.Lines, parseLine(content[offset:end]))
+		offset = end
+	}
+
+	return hosts
+}
+
+// String returns content of the hosts file.
+func (h *Hosts) String() string {
+	var builder strings.Builder
+	for _, line := range h.Lines {
+		builder.WriteString(line.Text)
+		builder.WriteString(line.Ending)
+	}
+
+	return builder.String()
+}
+
+// LineEnding returns the line ending used in the hosts file.
+func (h *Hosts) LineEnding() string {
+	for _, line := range h.Lines {
+		if line.Ending != "" {
+			return line.Ending
+		}
+	}
+
+	return "\n"
+}
+
+// HasBlock checks if the hosts file has the block of blocked domains.
+func (h *Hosts) HasBlock() bool {
+	for _, line := range h.Lines {
+		if line.isBlock() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Entries returns entries of the hosts file outside of the block.
+func (h *Hosts) Entries() []Line {
+	var entries []Line
+	for _, line := range h.Lines {
+		if line.Kind == LineEntry {
+			entries = append(entries, line)
+		}
+	}
+
+	return entries
+}
+
+// SetBlock replaces the block of blocked domains with the block, keeping
+// its position in the file. If there is no block, it's appended to the end
+// of the file. Other blocks and orphaned end tags are removed.
+// The block is written with the line ending of the hosts file, including
+// its last line, so the lines appended to the file later never join EndTag.
+func (h *Hosts) SetBlock(block string) {
+	lineEnding := h.LineEnding()
+	if lineEnding != "\n" {
+		block = strings.ReplaceAll(block, "\n", lineEnding)
+	}
+
+	text, _ := splitEnding(block)
+
+	for i, line := range h.Lines {
+		if !line.isBlock() {
+			continue
+		}
+
+		ending := line.Ending
+		if ending == "" {
+			ending = lineEnding
+		}
+
+		h.Lines[i] = Line{Kind: LineBlock, Text: text, Ending: ending, terminated: true}
+		h.removeBlocks(i)
+
+		return
+	}
+
+	h.removeBlocks(-1)
+	h.ensureTrailingEnding()
+	h.Lines = append(h.Lines, Line{Kind: LineBlock, Text: text, Ending: lineEnding, terminated: true})
+}
+
+// mainBlock returns the index of the line with the block kept by Repair:
+// the intact terminated one, otherwise the last terminated one.
+// It returns -1 if there is no terminated block.
+func (h *Hosts) mainBlock() int {
+	main := -1
+	for i, line := range h.Lines {
+		if line.Kind != LineBlock || !line.terminated {
+			continue
+		}
+
+		main = i
+		if Verify(line.Text) == IntegrityIntact {
+			break
+		}
+	}
+
+	return main
+}
+
+// RemoveBlocks removes all the blocks of blocked domains, including
+// duplicated ones, the ones that aren't terminated by EndTag and
+// orphaned end tags.
+func (h *Hosts) RemoveBlocks() {
+	h.removeBlocks(-1)
+}
+
+// removeBlocks removes all the blocks except the line with the index keep.
+func (h *Hosts) removeBlocks(keep int) {
+	lines := h.Lines[:0]
+	for i, line := range h.Lines {
+		if line.Kind != LineBlock || i == keep {
+			lines = append(lines, line)
+		}
+	}
+
+	h.Lines = lines
+}
+
+// ensureTrailingEnding adds the line ending to the last line, so the content
+// appended to the file starts from a new line.
+func (h *Hosts) ensureTrailingEnding() {
+	if len(h.Lines) == 0 {
+		return
+	}
+
+	last := &h.Lines[len(h.Lines)-1]
+	if last.Ending == "" {
+		last.Ending = h.LineEnding()
+	}
+}
+
+func parseLine(raw string) Line {
+	text, ending := splitEnding(raw)
+	line := Line{Text: text, Ending: ending}
+
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case trimmed == "":
+		line.Kind = LineBlank
+		return line
+	case strings.HasPrefix(trimmed, "#"):
+		line.Kind = LineComment
+		line.Comment = strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+		return line
+	}
+
+	entry, comment, _ := strings.Cut(text, "#")
+
+	fields := strings.Fields(entry)
+	if len(fields) < 2 {
+		line.Kind = LineInvalid
+		return line
+	}
+
+	if _, err := netip.ParseAddr(fields[0]); err != nil {
+		line.Kind = LineInvalid
+		return line
+	}
+
+	line.Kind = LineEntry
+	line.IP = fields[0]
+	line.Hostnames = fields[1:]
+	line.Comment = strings.TrimSpace(comment)
+
+	return line
+}
+
+// lineEnd returns the offset right after the line starting at offset.
+func lineEnd(content string, offset int) int {
+	end := strings.IndexByte(content[offset:], '\n')
+	if end == -1 {
+		return len(content)
+	}
+
+	return offset + end + 1
+}
+
+// splitEnding splits the line into its text and line ending.
+func splitEnding(line string) (string, string) {
+	switch {
+	case strings.HasSuffix(line, "\r\n"):
+		return strings.TrimSuffix(line, "\r\n"), "\r\n"
+	case strings.HasSuffix(line, "\n"):
+		return strings.TrimSuffix(line, "\n"), "\n"
+	}
+
+	return line, ""
+}
@@ -0,0 +1,103 @@
+package hostsfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	block := testBlock()
+
+	t.Run("parses lines of the hosts file", func(t *testing.T) {
+		content := "# Static table lookup for hostnames.\r\n" +
+			"\r\n" +
+			"127.0.0.1\tlocalhost   localhost.localdomain  # loopback\r\n" +
+			"fe80::1%lo0 localhost\r\n" +
+			"not an entry\r\n" +
+			block + "\r\n" +
+			"10.0.0.1 dev.local"
+
+		hosts := Parse(content)
+		require.Len(t, hosts.Lines, 7)
+
+		kinds := make([]LineKind, 0, len(hosts.Lines))
+		for _, line := range hosts.Lines {
+			kinds = append(kinds, line.Kind)
+		}
+		assert.Equal(t, []LineKind{LineComment, LineBlank, LineEntry, LineEntry, LineInvalid, LineBlock, LineEntry}, kinds)
+
+		entry := hosts.Lines[2]
+		assert.Equal(t, "127.0.0.1", entry.IP)
+		assert.Equal(t, "localhost", entry.Hostname())
+		assert.Equal(t, []string{"localhost.localdomain"}, entry.Aliases())
+		assert.Equal(t, "loopback", entry.Comment)
+		assert.Equal(t, "\r\n", entry.Ending)
+
+		assert.Len(t, hosts.Entries(), 3)
+		assert.True(t, hosts.HasBlock())
+		assert.Equal(t, "\r\n", hosts.LineEnding())
+		assert.Equal(t, content, hosts.String())
+	})
+
+	t.Run("keeps broken blocks as they are", func(t *testing.T) {
+		content := EndTag + "\n" + testHostsContent + block + "\n" + block + "\n" + StartTag + "127.0.0.1 example.com\n"
+
+		assert.Equal(t, content, Parse(content).String())
+	})
+}
+
+func TestSetBlock(t *testing.T) {
+	block := testBlock()
+
+	t.Run("starts the block from a new line", func(t *testing.T) {
+		hosts := Parse("127.0.0.1 localhost")
+		hosts.SetBlock(block)
+
+		assert.Equal(t, "127.0.0.1 localhost\n"+block+"\n", hosts.String())
+	})
+
+	t.Run("keeps lines appended after the block on their own lines", func(t *testing.T) {
+		hosts := Parse(testHostsContent)
+		hosts.SetBlock(block)
+
+		content := hosts.String() + "10.9.9.9 myserver.lan\n"
+		assert.Equal(t, IntegrityIntact, Verify(content))
+
+		hosts = Parse(content)
+		hosts.RemoveBlocks()
+		assert.Equal(t, testHostsContent+"10.9.9.9 myserver.lan\n", hosts.String())
+	})
+
+	t.Run("terminates the block only by the whole EndTag line", func(t *testing.T) {
+		content := testHostsContent + block + "10.9.9.9 myserver.lan\n"
+
+		assert.Contains(t, Parse(content).String(), "10.9.9.9 myserver.lan")
+		assert.Equal(t, []Problem{{Kind: ProblemOrphanedStart, Line: 3}}, Diagnose(content))
+	})
+
+	t.Run("replaces the block in place", func(t *testing.T) {
+		hosts := Parse(testHostsContent + block + "\n10.0.0.1 dev.local\n")
+
+		newBlock := strings.Replace(block, "example.com", "example.net", 1)
+		hosts.SetBlock(newBlock)
+
+		assert.Equal(t, testHostsContent+newBlock+"\n10.0.0.1 dev.local\n", hosts.String())
+	})
+
+	t.Run("keeps CRLF line endings", func(t *testing.T) {
+		content := "# comment \r\n127.0.0.1  localhost\r\n"
+
+		hosts := Parse(content)
+		hosts.SetBlock(block)
+
+		assert.Equal(t, content+strings.ReplaceAll(block, "\n", "\r\n")+"\r\n", hosts.String())
+		assert.Equal(t, IntegrityIntact, Verify(hosts.String()))
+		assert.Equal(t, []string{"example.com", "example.org"}, Domains(hosts.String()))
+
+		hosts.RemoveBlocks()
+		assert.Equal(t, content, hosts.String())
+	})
+}
@@ -1,28 +1,34 @@
 package hostsfile
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"net"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/WIttyJudge/adless/internal/config"
 	"github.com/WIttyJudge/adless/internal/http"
+	"github.com/WIttyJudge/adless/internal/source"
+	"github.com/WIttyJudge/adless/pkg/parser"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-const localhost = "127.0.0.1"
-
-// first part (before +): subdomain pattern.
-// second part (after +): top level domain (TLD) pattern.
-var validDomainRegexp = regexp.MustCompile(`^([a-z0-9_-]{0,63}\.)+[a-z0-9][a-z0-9-]{0,61}[a-z0-9]$`)
-
 // Processor is a structure that is responsible for processing blocklists,
 // whitelists and preparing the result to save to hosts file.
 type Processor struct {
-	config     *config.Config
-	httpClient *http.HTTP
+	config  *config.Config
+	loader  *source.Loader
+	version string
+	// hostsEntries are entries of the hosts file added by the user.
+	hostsEntries []Line
 }
 
 // Result contains multiple parsed blocklists.
@@ -31,37 +37,105 @@ type Result struct {
 	endTag             string
 	descriptionComment string
 	domains            map[string]LineContent
+	// hosts are domains mapped to specific addresses, they aren't blocked.
+	hosts map[string]LineContent
+	// sort is the order of domains in the hosts file.
+	sort string
+	// domainsPerLine is the maximum number of domains written on one line.
+	domainsPerLine int
+
+	header     Header
+	blocklists []TargetResult
+	whitelists []TargetResult
+	conflicts  []Conflict
+}
+
+// Conflict is a blocked domain that the user maps to another address
+// in the hosts file outside of the block.
+type Conflict struct {
+	Domain string
+	// IP is the address the user maps the domain to.
+	IP string
+	// Whitelisted is set if the domain isn't blocked because of the conflict.
+	Whitelisted bool
 }
 
 // TargetResult represents a parsed result of blocklist
 // that is ready to be appended into hosts file.
 type TargetResult struct {
+	Target       string
 	DomainsCount int
+	// Checksum is a checksum of the target content.
+	Checksum string
+	// Err is an error occurred while processing the target.
+	Err error
+	// Format is the format of the target content.
+	Format string
+	// Skipped is the number of skipped entries of the target by the reason.
+	Skipped map[parser.SkipReason]int
 
 	linesContent map[string]LineContent
+	// exceptions are domains allowed by @@ rules of the target.
+	exceptions map[string]LineContent
+	// important are domains blocked by rules that can't be overridden
+	// by exceptions.
+	important map[string]struct{}
+	// wildcards are domains of the whitelist allowing their subdomains too.
+	wildcards map[string]struct{}
+	// patterns are regular expressions of the regex list matching domains.
+	patterns []*regexp.Regexp
 }
 
 type LineContent struct {
-	ipAddress  string
-	domainName string
+	ipAddress string
+	// ipv6Address is the address of the paired IPv6 entry.
+	// The entry isn't written if it's empty.
+	ipv6Address string
+	domainName  string
+}
+
+// DefaultDomainsPerLine returns the number of domains written on one line
+// by default. The DNS client of Windows reads at most 9 domains from a line,
+// and packing them makes the hosts file much smaller and faster to parse.
+// Other systems get one domain per line, which every resolver supports.
+func DefaultDomainsPerLine() int {
+	if runtime.GOOS == "windows" {
+		return 9
+	}
+
+	return 1
 }
 
 // NewProcessor initializes Processor structure.
-func NewProcessor(config *config.Config) *Processor {
-	httpClient := http.New()
+// The version of adless is written to the header of the result.
+func NewProcessor(config *config.Config, version string) *Processor {
+	// Relative paths of local lists are resolved against the directory
+	// of the config file.
+	baseDir := ""
+	if config.Path != "" {
+		baseDir = filepath.Dir(config.Path)
+	}
 
 	return &Processor{
-		config:     config,
-		httpClient: httpClient,
+		config:  config,
+		loader:  source.New(http.New(), baseDir),
+		version: version,
 	}
 }
 
+// SetHostsEntries sets entries added by the user to the hosts file,
+// so the blocked domains conflicting with them are detected.
+func (p *Processor) SetHostsEntries(entries []Line) {
+	p.hostsEntries = entries
+}
+
 // Process processes blocklists and returns a finished result
 // that is ready to save to hosts file.
 func (p *Processor) Process() (Result, error) {
 	wg := &sync.WaitGroup{}
 	blocklistsResult := p.processBlocklists(wg)
 	whitelistsResult := p.processWhitelists(wg)
+	hostsResult := p.processHosts(wg)
 	wg.Wait()
 
 	// Merges the results of all targets into one map, where the key
@@ -70,16 +144,39 @@ func (p *Processor) Process() (Result, error) {
 	blocklistDomains := p.targetDomains(blocklistsResult)
 	whitelistDomains := p.targetDomains(whitelistsResult)
 
-	p.applyWhitelist(blocklistDomains, whitelistDomains)
+	p.applyRegexBlock(blocklistDomains, blocklistsResult)
+	p.applyExceptions(whitelistDomains, blocklistsResult, whitelistsResult)
+	p.applyWhitelist(blocklistDomains, whitelistDomains, whitelistsResult)
+	p.applyRegexAllow(blocklistDomains, whitelistsResult)
+	conflicts := p.resolveConflicts(blocklistDomains)
+	hostsDomains := p.targetDomains(hostsResult)
+	p.applyHosts(blocklistDomains, hostsDomains)
 
 	result := Result{
 		startTag:           StartTag,
 		endTag:             EndTag,
 		descriptionComment: DescriptionComment,
 		domains:            blocklistDomains,
+		hosts:              hostsDomains,
+		sort:               p.config.Output.Sort,
+		domainsPerLine:     cmp.Or(p.config.Output.DomainsPerLine, DefaultDomainsPerLine()),
+		header: Header{
+			Version:     p.version,
+			GeneratedAt: time.Now().UTC().Truncate(time.Second),
+			ConfigPath:  p.config.Path,
+			Blocklists:  newSources(blocklistsResult),
+			Whitelists:  newSources(whitelistsResult),
+			Hosts:       newSources(hostsResult),
+		},
+		blocklists: blocklistsResult,
+		whitelists: whitelistsResult,
+		conflicts:  conflicts,
 	}
 
 	log.Info().Msgf("total number of uniq domains: %d", len(blocklistDomains))
+	if len(hostsDomains) > 0 {
+		log.Info().Msgf("total number of mapped domains: %d", len(hostsDomains))
+	}
 
 	return result, nil
 }
@@ -89,6 +186,7 @@ func (p *Processor) processBlocklists(wg *sync.WaitGroup) []TargetResult {
 
 	for i, blocklist := range p.config.Blocklists {
 		i := i
+		blocklist := blocklist
 		target := blocklist.Target
 
 		wg.Add(1)
@@ -96,9 +194,10 @@ func (p *Processor) processBlocklists(wg *sync.WaitGroup) []TargetResult {
 		go func() {
 			defer wg.Done()
 
-			blocklistResult, err := p.processBlocklist(target)
+			blocklistResult, err := p.processBlocklist(blocklist)
 			if err != nil {
 				log.Error().Err(err).Str("target", target).Msg("failed to process blocklist")
+				blocklistsResult[i] = TargetResult{Target: target, Err: err}
 				return
 			}
 
@@ -110,10 +209,11 @@ func (p *Processor) processBlocklists(wg *sync.WaitGroup) []TargetResult {
 }
 
 func (p *Processor) processWhitelists(wg *sync.WaitGroup) []TargetResult {
-	whitelistsResult := make([]TargetResult, len(p.config.Blocklists))
+	whitelistsResult := make([]TargetResult, len(p.config.Whitelists))
 
 	for i, whitelist := range p.config.Whitelists {
 		i := i
+		whitelist := whitelist
 		target := whitelist.Target
 
 		wg.Add(1)
@@ -121,9 +221,10 @@ func (p *Processor) processWhitelists(wg *sync.WaitGroup) []TargetResult {
 		go func() {
 			defer wg.Done()
 
-			whitelistResult, err := p.processWhitelist(target)
+			whitelistResult, err := p.processWhitelist(whitelist)
 			if err != nil {
 				log.Error().Err(err).Str("target", target).Msg("failed to process whitelist")
+				whitelistsResult[i] = TargetResult{Target: target, Err: err}
 				return
 			}
 
@@ -134,23 +235,108 @@ func (p *Processor) processWhitelists(wg *sync.WaitGroup) []TargetResult {
 	return whitelistsResult
 }
 
-func (p *Processor) processBlocklist(target string) (TargetResult, error) {
+// processHosts processes domains mapped to specific addresses. Targets are
+// processed concurrently, and domains from the config are used as is.
+func (p *Processor) processHosts(wg *sync.WaitGroup) []TargetResult {
+	hostsResult := make([]TargetResult, len(p.config.Hosts))
+
+	for i, host := range p.config.Hosts {
+		i := i
+		target := host.Target
+
+		if target == "" {
+			domain := strings.ToLower(host.Domain)
+			hostsResult[i] = TargetResult{
+				DomainsCount: 1,
+				linesContent: map[string]LineContent{
+					domain: {ipAddress: host.IP, domainName: domain},
+				},
+			}
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			hostResult, err := p.processHostsTarget(target)
+			if err != nil {
+				log.Error().Err(err).Str("target", target).Msg("failed to process hosts")
+				hostsResult[i] = TargetResult{Target: target, Err: err}
+				return
+			}
+
+			hostsResult[i] = hostResult
+		}()
+	}
+
+	return hostsResult
+}
+
+// processHostsTarget processes the target with domains mapped to addresses
+// in the hosts file format.
+func (p *Processor) processHostsTarget(target string) (TargetResult, error) {
+	log.Info().Str("target", target).Msg("processing hosts..")
+
+	fileContent, err := p.loader.Load(target, "")
+	if err != nil {
+		return TargetResult{}, err
+	}
+
+	linesContent := make(map[string]LineContent)
+	for _, entry := range Parse(fileContent).Entries() {
+		for _, hostname := range entry.Hostnames {
+			domain := strings.ToLower(hostname)
+			if parser.IsReservedDomain(domain) || !parser.IsValidDomain(domain) {
+				continue
+			}
+
+			if _, ok := linesContent[domain]; !ok {
+				linesContent[domain] = LineContent{ipAddress: entry.IP, domainName: domain}
+			}
+		}
+	}
+
+	log.Info().Str("target", target).Msgf("number of domains: %d", len(linesContent))
+
+	return TargetResult{
+		Target:       target,
+		DomainsCount: len(linesContent),
+		Checksum:     checksum(fileContent),
+		linesContent: linesContent,
+	}, nil
+}
+
+func (p *Processor) processBlocklist(blocklist config.Domainlist) (TargetResult, error) {
+	target := blocklist.Target
 	log.Info().Str("target", target).Msg("processing blocklist..")
 
-	blocklistResult, err := p.proccessListTarget(target)
+	blocklistResult, err := p.proccessListTarget(blocklist)
 	if err != nil {
 		return TargetResult{}, err
 	}
 
+	blocklistResult.applyExceptions()
+
+	ipAddress, ipv6Address := p.sinks(blocklist)
+	for domain, line := range blocklistResult.linesContent {
+		line.ipAddress = ipAddress
+		line.ipv6Address = ipv6Address
+		blocklistResult.linesContent[domain] = line
+	}
+
 	log.Info().Str("target", target).Msgf("number of domains: %d", blocklistResult.DomainsCount)
 
 	return blocklistResult, nil
 }
 
-func (p *Processor) processWhitelist(target string) (TargetResult, error) {
+func (p *Processor) processWhitelist(whitelist config.Domainlist) (TargetResult, error) {
+	target := whitelist.Target
 	log.Info().Str("target", target).Msg("processing whitelist..")
 
-	whitelistResult, err := p.proccessListTarget(target)
+	whitelistResult, err := p.proccessListTarget(whitelist)
 	if err != nil {
 		return TargetResult{}, err
 	}
@@ -160,167 +346,414 @@ func (p *Processor) processWhitelist(target string) (TargetResult, error) {
 	return whitelistResult, nil
 }
 
-func (p *Processor) proccessListTarget(target string) (TargetResult, error) {
-	fileContent, err := p.httpClient.Get(target)
+func (p *Processor) proccessListTarget(list config.Domainlist) (TargetResult, error) {
+	target := list.Target
+
+	// Inline domains are parsed as wildcards, so whitelists may allow
+	// subdomains of them.
+	inline, err := p.processContent(strings.Join(list.Domains, "\n"), config.Domainlist{
+		Format:     parser.FormatWildcard,
+		Subdomains: list.Subdomains,
+	})
 	if err != nil {
 		return TargetResult{}, err
 	}
 
-	linesContent := p.processContent(fileContent)
+	if target == "" {
+		logSkipped("inline", inline.Skipped)
+		return inline, nil
+	}
 
-	blocklistResult := TargetResult{
-		DomainsCount: len(linesContent),
-		linesContent: linesContent,
+	fileContent, err := p.loader.Load(target, list.Entry)
+	if err != nil {
+		return TargetResult{}, err
+	}
+
+	blocklistResult, err := p.processContent(fileContent, list)
+	if err != nil {
+		return TargetResult{}, err
 	}
+	blocklistResult.Target = target
+	blocklistResult.Checksum = checksum(fileContent)
+	blocklistResult.merge(inline)
+
+	log.Debug().Str("target", target).Str("format", blocklistResult.Format).Msg("list format")
+	logSkipped(target, blocklistResult.Skipped)
 
 	return blocklistResult, nil
 }
 
-func (p *Processor) processContent(content string) map[string]LineContent {
-	lines := strings.Split(content, "\n")
-	linesContent := make(map[string]LineContent)
+// applyExceptions removes domains allowed by exception rules of the list
+// from its domains, unless they're blocked by important rules. Exceptions
+// of a blocklist don't affect other blocklists.
+func (r *TargetResult) applyExceptions() {
+	for domain := range r.exceptions {
+		if _, ok := r.important[domain]; !ok {
+			delete(r.linesContent, domain)
+		}
+	}
 
-	for _, rawLine := range lines {
-		line := p.normalizeLine(rawLine)
+	r.DomainsCount = len(r.linesContent)
+}
 
-		if p.shouldSkipLine(line) {
-			continue
-		}
+// merge adds domains of the other result to the result.
+func (r *TargetResult) merge(other TargetResult) {
+	maps.Copy(r.linesContent, other.linesContent)
+	maps.Copy(r.exceptions, other.exceptions)
+	maps.Copy(r.important, other.important)
+	maps.Copy(r.wildcards, other.wildcards)
+	r.patterns = append(r.patterns, other.patterns...)
 
-		domainName := p.extractDomain(line)
-		if p.IsSkippedDomain(domainName) {
-			continue
-		}
+	for reason, count := range other.Skipped {
+		r.Skipped[reason] += count
+	}
 
-		if !p.isValidDomain(domainName) {
-			continue
-		}
+	r.DomainsCount = len(r.linesContent)
+}
 
-		lineContent := LineContent{
-			ipAddress:  localhost,
-			domainName: domainName,
+// processContent parses content of the list and returns its domains,
+// domains allowed by exception rules and the number of skipped entries
+// by the reason.
+func (p *Processor) processContent(content string, list config.Domainlist) (TargetResult, error) {
+	prs, err := listParser(content, list)
+	if err != nil {
+		return TargetResult{}, err
+	}
+
+	parsed := prs.Parse(content)
+
+	result := TargetResult{
+		DomainsCount: len(parsed.Domains),
+		Format:       prs.Name(),
+		Skipped:      parsed.Skipped,
+		linesContent: make(map[string]LineContent, len(parsed.Domains)),
+		exceptions:   make(map[string]LineContent, len(parsed.Exceptions)),
+		important:    make(map[string]struct{}, len(parsed.Important)),
+		wildcards:    make(map[string]struct{}, len(parsed.Wildcards)),
+	}
+
+	for _, domain := range parsed.Domains {
+		result.linesContent[domain] = LineContent{domainName: domain}
+	}
+	for _, domain := range parsed.Exceptions {
+		result.exceptions[domain] = LineContent{domainName: domain}
+	}
+	for _, domain := range parsed.Important {
+		result.important[domain] = struct{}{}
+	}
+
+	wildcards := parsed.Wildcards
+	if list.Subdomains {
+		wildcards = parsed.Domains
+	}
+	for _, domain := range wildcards {
+		result.wildcards[domain] = struct{}{}
+	}
+
+	// Patterns are already validated by the parser.
+	for _, pattern := range parsed.Patterns {
+		result.patterns = append(result.patterns, regexp.MustCompile(pattern))
+	}
+
+	return result, nil
+}
+
+// listParser returns the parser of the list in its format. If the format
+// is empty, it's detected from the content, unless the list has CSV options.
+func listParser(content string, list config.Domainlist) (parser.Parser, error) {
+	if list.CSV != nil {
+		options := parser.CSVOptions{Column: list.CSV.Column, Header: list.CSV.Header}
+		if list.Format == parser.FormatTSV {
+			options.Comma = '\t'
 		}
 
-		linesContent[domainName] = lineContent
+		return parser.NewCSVParser(cmp.Or(list.Format, parser.FormatCSV), options)
+	}
+
+	if list.Format == "" {
+		return parser.Detect(content), nil
+	}
+
+	prs, ok := parser.Lookup(list.Format)
+	if !ok {
+		return nil, fmt.Errorf("unknown list format %q", list.Format)
+	}
+
+	return prs, nil
+}
+
+// logSkipped reports the number of skipped entries of the target by the reason.
+func logSkipped(target string, skipped map[parser.SkipReason]int) {
+	reasons := make([]parser.SkipReason, 0, len(skipped))
+	for reason := range skipped {
+		reasons = append(reasons, reason)
 	}
+	slices.Sort(reasons)
+
+	for _, reason := range reasons {
+		var event *zerolog.Event
+		switch reason {
+		case parser.SkipRealIP, parser.SkipMissingColumn:
+			event = log.Warn()
+		case parser.SkipUnsupportedRule:
+			event = log.Info()
+		default:
+			event = log.Debug()
+		}
 
-	return linesContent
+		event.Str("target", target).Str("reason", string(reason)).Int("count", skipped[reason]).Msg("entries skipped")
+	}
 }
 
 func (p *Processor) targetDomains(targetResult []TargetResult) map[string]LineContent {
 	targetDomains := make(map[string]LineContent)
 
+	// If a domain is in several lists, the first one in order of the config
+	// wins, so the domain points to the sink of that list.
 	for _, result := range targetResult {
 		for _, line := range result.linesContent {
-			targetDomains[line.domainName] = line
+			if _, ok := targetDomains[line.domainName]; !ok {
+				targetDomains[line.domainName] = line
+			}
 		}
 	}
 
 	return targetDomains
 }
 
-func (p *Processor) applyWhitelist(blocklistDomains, whitelistDomains map[string]LineContent) {
+// sinks returns the address domains of the blocklist point to and
+// the address of paired IPv6 entries, which is empty if they're disabled.
+func (p *Processor) sinks(blocklist config.Domainlist) (string, string) {
+	output := p.config.Output
+
+	sink := cmp.Or(blocklist.Sink, output.Sink, config.DefaultSink)
+	if !output.IPv6 || net.ParseIP(sink).To4() == nil {
+		return sink, ""
+	}
+
+	if output.IPv6Sink != "" {
+		return sink, output.IPv6Sink
+	}
+
+	if sink == "0.0.0.0" {
+		return sink, "::"
+	}
+
+	return sink, "::1"
+}
+
+// resolveConflicts finds blocked domains the user maps to other addresses
+// in the hosts file. If it's configured, these domains are whitelisted.
+func (p *Processor) resolveConflicts(blocklistDomains map[string]LineContent) []Conflict {
+	var conflicts []Conflict
+
+	for _, entry := range p.hostsEntries {
+		for _, hostname := range entry.Hostnames {
+			domain := strings.ToLower(hostname)
+
+			line, ok := blocklistDomains[domain]
+			if !ok || entry.IP == line.ipAddress || entry.IP == line.ipv6Address {
+				continue
+			}
+
+			conflicts = append(conflicts, Conflict{
+				Domain:      domain,
+				IP:          entry.IP,
+				Whitelisted: p.config.WhitelistHostsEntries,
+			})
+
+			if p.config.WhitelistHostsEntries {
+				delete(blocklistDomains, domain)
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// applyHosts removes domains mapped to specific addresses from blocked ones,
+// as mappings take precedence over blocking.
+func (p *Processor) applyHosts(blocklistDomains, hostsDomains map[string]LineContent) {
+	for domain := range hostsDomains {
+		delete(blocklistDomains, domain)
+	}
+}
+
+// applyExceptions adds domains allowed by exception rules of whitelists
+// to the whitelist, unless blocklists block them with important rules.
+// Exceptions of blocklists are applied to their own domains only.
+func (p *Processor) applyExceptions(whitelistDomains map[string]LineContent, blocklistsResult, whitelistsResult []TargetResult) {
+	important := make(map[string]struct{})
+	for _, result := range blocklistsResult {
+		for domain := range result.important {
+			important[domain] = struct{}{}
+		}
+	}
+
+	for _, result := range whitelistsResult {
+		for domain, line := range result.exceptions {
+			if _, ok := important[domain]; !ok {
+				whitelistDomains[domain] = line
+			}
+		}
+	}
+}
+
+// applyWhitelist removes whitelisted domains from blocked ones, including
+// subdomains of whitelisted wildcards.
+func (p *Processor) applyWhitelist(blocklistDomains, whitelistDomains map[string]LineContent, whitelistsResult []TargetResult) {
 	for key := range whitelistDomains {
 		delete(blocklistDomains, key)
 	}
-}
 
-// 1. Remove empty spaces.
-// 2. Remove a comment in the middle of line.
-// 3. Convert all characters to lowercase.
-func (p *Processor) normalizeLine(rawLine string) string {
-	line := strings.TrimSpace(rawLine)
-	line = p.removeInLineComment(line)
-	line = strings.ToLower(line)
+	wildcards := newDomainTrie()
+	for _, result := range whitelistsResult {
+		for domain := range result.wildcards {
+			wildcards.add(domain)
+		}
+	}
+
+	if wildcards.empty() {
+		return
+	}
 
-	return line
+	for domain := range blocklistDomains {
+		if wildcards.match(domain) {
+			delete(blocklistDomains, domain)
+		}
+	}
 }
 
-// skip empty lines, comments, ABP comments and ABP headers.
-func (p *Processor) shouldSkipLine(line string) bool {
-	return line == "" || p.isLineComment(line) || p.isABPComment(line) || p.isABPHeader(line)
+// applyRegexBlock blocks domains of the corpus matching regex block rules
+// of the config and regex blocklists. They point to the global sink.
+func (p *Processor) applyRegexBlock(blocklistDomains map[string]LineContent, blocklistsResult []TargetResult) {
+	patterns := regexRules(p.config.Regex.Block, blocklistsResult)
+	if len(patterns) == 0 {
+		return
+	}
+
+	if len(p.config.Regex.Corpus) == 0 {
+		log.Warn().Msg("regex block rules have no corpus of domains to match")
+		return
+	}
+
+	ipAddress, ipv6Address := p.sinks(config.Domainlist{})
+
+	count := 0
+	for _, domain := range p.corpusDomains() {
+		if _, ok := blocklistDomains[domain]; ok || !matchAny(patterns, domain) {
+			continue
+		}
+
+		blocklistDomains[domain] = LineContent{ipAddress: ipAddress, ipv6Address: ipv6Address, domainName: domain}
+		count++
+	}
+
+	log.Info().Msgf("number of domains blocked by regex rules: %d", count)
 }
 
-func (p *Processor) removeInLineComment(line string) string {
-	return strings.Split(line, "#")[0]
+// applyRegexAllow removes blocked domains matching regex allow rules
+// of the config and regex whitelists.
+func (p *Processor) applyRegexAllow(blocklistDomains map[string]LineContent, whitelistsResult []TargetResult) {
+	patterns := regexRules(p.config.Regex.Allow, whitelistsResult)
+	if len(patterns) == 0 {
+		return
+	}
+
+	for domain := range blocklistDomains {
+		if matchAny(patterns, domain) {
+			delete(blocklistDomains, domain)
+		}
+	}
 }
 
-func (p *Processor) parseABPDomain(line string) string {
-	line = strings.TrimPrefix(line, "||")
-	line = strings.TrimSuffix(line, "^")
-	return line
+// corpusDomains returns domains of the corpus files. Files that can't be
+// read are reported and skipped.
+func (p *Processor) corpusDomains() []string {
+	var domains []string
+	for _, path := range p.config.Regex.Corpus {
+		content, err := p.loader.Load(path, "")
+		if err != nil {
+			log.Error().Err(err).Str("path", path).Msg("failed to read regex corpus")
+			continue
+		}
+
+		domains = append(domains, parser.Detect(content).Parse(content).Domains...)
+	}
+
+	return domains
 }
 
-func (p *Processor) extractDomain(line string) string {
-	if p.isABPDomain(line) {
-		line = p.parseABPDomain(line)
+// regexRules returns compiled rules of the config, which are validated
+// on loading, followed by patterns of the regex lists.
+func regexRules(rules []string, results []TargetResult) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(rules))
+	for _, rule := range rules {
+		patterns = append(patterns, regexp.MustCompile(rule))
 	}
 
-	var domainName string
-	parts := strings.Fields(line)
-	if len(parts) == 1 {
-		domainName = parts[0]
-	} else {
-		domainName = parts[1]
+	for _, result := range results {
+		patterns = append(patterns, result.patterns...)
 	}
 
-	return domainName
+	return patterns
 }
 
-func (p *Processor) isLineComment(line string) bool {
-	return strings.HasPrefix(line, "#")
+func matchAny(patterns []*regexp.Regexp, domain string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(domain) {
+			return true
+		}
+	}
+
+	return false
 }
 
-// supported ABP style: ||subdomain.domain.tlp^
-// Example: https://raw.githubusercontent.com/hagezi/dns-blocklists/main/adblock/light.txt
-func (p *Processor) isABPDomain(line string) bool {
-	return strings.HasPrefix(line, "||") && strings.HasSuffix(line, "^")
+// Header returns the header describing how the result was generated.
+func (r Result) Header() Header {
+	return r.header
 }
 
-func (p *Processor) isABPComment(line string) bool {
-	return strings.HasPrefix(line, "!")
+// Blocklists returns results of processing each blocklist.
+func (r Result) Blocklists() []TargetResult {
+	return r.blocklists
 }
 
-func (p *Processor) isABPHeader(line string) bool {
-	return strings.HasPrefix(line, "[")
+// Whitelists returns results of processing each whitelist.
+func (r Result) Whitelists() []TargetResult {
+	return r.whitelists
 }
 
-// IsSkippedDomain checks if a domain is in the skip list.
-// Some lists (i.e StevenBlack's) contain these as they are supposed to be used as HOST.
-func (p *Processor) IsSkippedDomain(domain string) bool {
-	skipList := []string{
-		"localhost",
-		"localhost.localdomain",
-		"local",
-		"broadcasthost",
-		"ip6-localhost",
-		"ip6-loopback",
-		"lo0 localhost",
-		"ip6-localnet",
-		"ip6-mcastprefix",
-		"ip6-allnodes",
-		"ip6-allrouters",
-		"ip6-allhosts",
-		"0.0.0.0",
-	}
+// Conflicts returns blocked domains conflicting with entries of the hosts file.
+func (r Result) Conflicts() []Conflict {
+	return r.conflicts
+}
 
-	return slices.Contains(skipList, domain)
+// DomainsCount returns the number of domains to be blocked.
+func (r Result) DomainsCount() int {
+	return len(r.domains)
 }
 
-func (p *Processor) isValidDomain(domain string) bool {
-	return validDomainRegexp.MatchString(domain)
+// MappingsCount returns the number of domains mapped to addresses by hosts.
+func (r Result) MappingsCount() int {
+	return len(r.hosts)
 }
 
 func (r Result) FormatToHostsfile() string {
 	var builder strings.Builder
 
-	builder.WriteString(r.startTag)
-	builder.WriteString(r.descriptionComment)
+	entries := r.formatEntries()
 
-	for _, domain := range r.domains {
-		builder.WriteString(domain.Format())
-	}
+	// The checksum of entries allows to detect if the block was modified.
+	header := r.header
+	header.DomainsCount = len(r.domains)
+	header.MappingsCount = len(r.hosts)
+	header.Checksum = checksum(entries)
 
+	builder.WriteString(r.startTag)
+	builder.WriteString(r.descriptionComment)
+	builder.WriteString(header.Format())
+	builder.WriteString(entries)
 	builder.WriteString(r.endTag)
 
 	withoutLastWhitespace := strings.TrimSuffix(builder.String(), "\n")
@@ -328,6 +761,78 @@ func (r Result) FormatToHostsfile() string {
 	return withoutLastWhitespace
 }
 
+// formatEntries returns lines of blocked domains followed by lines of mapped
+// ones, so mapped domains can be told apart by their number in the header.
+// Consecutive domains pointing to the same address are packed into one line
+// up to domainsPerLine.
+func (r Result) formatEntries() string {
+	var builder strings.Builder
+
+	perLine := max(r.domainsPerLine, 1)
+	line := make([]string, 0, perLine)
+
+	var sink LineContent
+	writeLine := func() {
+		if len(line) == 0 {
+			return
+		}
+
+		builder.WriteString(sink.ipAddress + " " + strings.Join(line, " ") + "\n")
+		if sink.ipv6Address != "" {
+			builder.WriteString(sink.ipv6Address + " " + strings.Join(line, " ") + "\n")
+		}
+
+		line = line[:0]
+	}
+
+	for _, entries := range []map[string]LineContent{r.domains, r.hosts} {
+		for _, domain := range r.sortedDomains(entries) {
+			content := entries[domain]
+			if len(line) == perLine || content.ipAddress != sink.ipAddress || content.ipv6Address != sink.ipv6Address {
+				writeLine()
+				sink = content
+			}
+
+			line = append(line, domain)
+		}
+		writeLine()
+	}
+
+	return builder.String()
+}
+
+// sortedDomains returns domain names in the configured order, so the same
+// domains are always written to the hosts file the same way.
+func (r Result) sortedDomains(entries map[string]LineContent) []string {
+	domains := make([]string, 0, len(entries))
+	for domain := range entries {
+		domains = append(domains, domain)
+	}
+
+	if r.sort != config.SortDomain {
+		slices.Sort(domains)
+		return domains
+	}
+
+	// Labels are reversed once instead of on every comparison.
+	keys := make(map[string][]string, len(domains))
+	for _, domain := range domains {
+		labels := strings.Split(domain, ".")
+		slices.Reverse(labels)
+		keys[domain] = labels
+	}
+
+	slices.SortFunc(domains, func(a, b string) int {
+		return slices.Compare(keys[a], keys[b])
+	})
+
+	return domains
+}
+
 func (lc LineContent) Format() string {
-	return fmt.Sprintf("%s %s\n", lc.ipAddress, lc.domainName)
+	if lc.ipv6Address == "" {
+		return fmt.Sprintf("%s %s\n", lc.ipAddress, lc.domainName)
+	}
+
+	return fmt.Sprintf("%s %s\n%s %s\n", lc.ipAddress, lc.domainName, lc.ipv6Address, lc.domainName)
 }
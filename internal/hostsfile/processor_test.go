@@ -1,5 +1,490 @@
 package hostsfile
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WIttyJudge/adless/internal/config"
+	"github.com/WIttyJudge/adless/internal/source"
+	"github.com/WIttyJudge/adless/pkg/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
 func TestIsLineComment(_ *testing.T) {}
+
+func TestSortedDomains(t *testing.T) {
+	newResult := func(sort string) Result {
+		domains := make(map[string]LineContent)
+		for _, domain := range []string{"b.example.org", "ads.example.com", "example.org", "a-b.example.com", "tracker.net"} {
+			domains[domain] = LineContent{ipAddress: config.DefaultSink, domainName: domain}
+		}
+
+		return Result{
+			startTag:           StartTag,
+			endTag:             EndTag,
+			descriptionComment: DescriptionComment,
+			domains:            domains,
+			sort:               sort,
+		}
+	}
+
+	sortedDomains := func(sort string) []string {
+		result := newResult(sort)
+		return result.sortedDomains(result.domains)
+	}
+
+	t.Run("alphabetical", func(t *testing.T) {
+		expected := []string{"a-b.example.com", "ads.example.com", "b.example.org", "example.org", "tracker.net"}
+		assert.Equal(t, expected, sortedDomains(config.SortAlphabetical))
+		assert.Equal(t, expected, sortedDomains(""))
+	})
+
+	t.Run("grouped by domain", func(t *testing.T) {
+		expected := []string{"a-b.example.com", "ads.example.com", "tracker.net", "example.org", "b.example.org"}
+		assert.Equal(t, expected, sortedDomains(config.SortDomain))
+	})
+
+	t.Run("formats the same domains the same way", func(t *testing.T) {
+		first := newResult(config.SortDomain).FormatToHostsfile()
+		for range 10 {
+			assert.Equal(t, first, newResult(config.SortDomain).FormatToHostsfile())
+		}
+		assert.Less(t, strings.Index(first, " example.org\n"), strings.Index(first, " b.example.org\n"))
+	})
+}
+
+func TestSinks(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       config.Output
+		blocklist    config.Domainlist
+		expectedIPv4 string
+		expectedIPv6 string
+	}{
+		{
+			name:         "default sink",
+			expectedIPv4: config.DefaultSink,
+		},
+		{
+			name:         "sink of the list has priority",
+			output:       config.Output{Sink: "0.0.0.0"},
+			blocklist:    config.Domainlist{Sink: "10.0.0.1"},
+			expectedIPv4: "10.0.0.1",
+		},
+		{
+			name:         "paired IPv6 entry for 0.0.0.0",
+			output:       config.Output{Sink: "0.0.0.0", IPv6: true},
+			expectedIPv4: "0.0.0.0",
+			expectedIPv6: "::",
+		},
+		{
+			name:         "paired IPv6 entry for custom sink",
+			output:       config.Output{Sink: "10.0.0.1", IPv6: true},
+			expectedIPv4: "10.0.0.1",
+			expectedIPv6: "::1",
+		},
+		{
+			name:         "configured IPv6 sink",
+			output:       config.Output{IPv6: true, IPv6Sink: "fd00::1"},
+			expectedIPv4: config.DefaultSink,
+			expectedIPv6: "fd00::1",
+		},
+		{
+			name:         "no paired entry for IPv6 sink",
+			output:       config.Output{Sink: "::", IPv6: true},
+			expectedIPv4: "::",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProcessor(&config.Config{Output: tt.output}, "")
+
+			ipAddress, ipv6Address := p.sinks(tt.blocklist)
+			assert.Equal(t, tt.expectedIPv4, ipAddress)
+			assert.Equal(t, tt.expectedIPv6, ipv6Address)
+		})
+	}
+}
+
+func TestTargetDomains(t *testing.T) {
+	t.Run("first list wins", func(t *testing.T) {
+		results := []TargetResult{
+			{linesContent: map[string]LineContent{
+				"example.com": {ipAddress: "0.0.0.0", domainName: "example.com"},
+			}},
+			{linesContent: map[string]LineContent{
+				"example.com": {ipAddress: "10.0.0.1", domainName: "example.com"},
+				"example.org": {ipAddress: "10.0.0.1", domainName: "example.org"},
+			}},
+		}
+
+		domains := (&Processor{}).targetDomains(results)
+		assert.Equal(t, "0.0.0.0", domains["example.com"].ipAddress)
+		assert.Equal(t, "10.0.0.1", domains["example.org"].ipAddress)
+	})
+}
+
+func TestFormatEntries(t *testing.T) {
+	newLine := func(ipAddress, ipv6Address, domain string) LineContent {
+		return LineContent{ipAddress: ipAddress, ipv6Address: ipv6Address, domainName: domain}
+	}
+
+	t.Run("packs domains pointing to the same sink", func(t *testing.T) {
+		result := Result{
+			domains: map[string]LineContent{
+				"a.com": newLine("0.0.0.0", "", "a.com"),
+				"b.com": newLine("0.0.0.0", "", "b.com"),
+				"c.com": newLine("0.0.0.0", "", "c.com"),
+				"d.com": newLine("10.0.0.1", "", "d.com"),
+				"e.com": newLine("0.0.0.0", "", "e.com"),
+			},
+			domainsPerLine: 2,
+		}
+
+		expected := "0.0.0.0 a.com b.com\n0.0.0.0 c.com\n10.0.0.1 d.com\n0.0.0.0 e.com\n"
+		assert.Equal(t, expected, result.formatEntries())
+	})
+
+	t.Run("writes mapped domains after blocked ones", func(t *testing.T) {
+		result := Result{
+			domains: map[string]LineContent{
+				"b.com": newLine("0.0.0.0", "", "b.com"),
+			},
+			hosts: map[string]LineContent{
+				"a.com": newLine("10.0.0.5", "", "a.com"),
+			},
+			domainsPerLine: 1,
+		}
+
+		assert.Equal(t, "0.0.0.0 b.com\n10.0.0.5 a.com\n", result.formatEntries())
+	})
+
+	t.Run("packs paired IPv6 entries", func(t *testing.T) {
+		result := Result{
+			domains: map[string]LineContent{
+				"a.com": newLine("0.0.0.0", "::", "a.com"),
+				"b.com": newLine("0.0.0.0", "::", "b.com"),
+			},
+			domainsPerLine: 9,
+		}
+
+		assert.Equal(t, "0.0.0.0 a.com b.com\n:: a.com b.com\n", result.formatEntries())
+	})
+
+	t.Run("packed block can be parsed back", func(t *testing.T) {
+		result := Result{
+			startTag:           StartTag,
+			endTag:             EndTag,
+			descriptionComment: DescriptionComment,
+			domains: map[string]LineContent{
+				"a.com": newLine("0.0.0.0", "::", "a.com"),
+				"b.com": newLine("0.0.0.0", "::", "b.com"),
+				"c.com": newLine("0.0.0.0", "::", "c.com"),
+			},
+			domainsPerLine: 2,
+		}
+
+		content := testHostsContent + result.FormatToHostsfile()
+
+		assert.Equal(t, []string{"a.com", "b.com", "c.com"}, Domains(content))
+		assert.Equal(t, IntegrityIntact, Verify(content))
+		hosts := Parse(content)
+		hosts.RemoveBlocks()
+		assert.Equal(t, testHostsContent, hosts.String())
+	})
+}
+
+func TestResolveConflicts(t *testing.T) {
+	newDomains := func() map[string]LineContent {
+		return map[string]LineContent{
+			"api.example.com": {ipAddress: "0.0.0.0", ipv6Address: "::", domainName: "api.example.com"},
+			"ads.example.com": {ipAddress: "0.0.0.0", ipv6Address: "::", domainName: "ads.example.com"},
+			"tracker.net":     {ipAddress: "0.0.0.0", ipv6Address: "::", domainName: "tracker.net"},
+		}
+	}
+
+	entries := Parse(testHostsContent +
+		"10.0.0.1 API.example.com\n" +
+		"0.0.0.0 ads.example.com\n" +
+		":: tracker.net\n").Entries()
+
+	t.Run("whitelists domains mapped by the user", func(t *testing.T) {
+		p := NewProcessor(&config.Config{WhitelistHostsEntries: true}, "")
+		p.SetHostsEntries(entries)
+
+		domains := newDomains()
+		conflicts := p.resolveConflicts(domains)
+
+		assert.Equal(t, []Conflict{{Domain: "api.example.com", IP: "10.0.0.1", Whitelisted: true}}, conflicts)
+		assert.NotContains(t, domains, "api.example.com")
+		assert.Len(t, domains, 2)
+	})
+
+	t.Run("only reports conflicts if whitelisting is disabled", func(t *testing.T) {
+		p := NewProcessor(&config.Config{}, "")
+		p.SetHostsEntries(entries)
+
+		domains := newDomains()
+		conflicts := p.resolveConflicts(domains)
+
+		assert.Equal(t, []Conflict{{Domain: "api.example.com", IP: "10.0.0.1"}}, conflicts)
+		assert.Len(t, domains, 3)
+	})
+}
+
+func TestProcessHosts(t *testing.T) {
+	p := NewProcessor(&config.Config{
+		Hosts: []config.Host{
+			{Domain: "Dev.Internal", IP: "10.0.0.5"},
+			{Domain: "www.google.com", IP: "216.239.38.120"},
+			{Domain: "dev.internal", IP: "10.0.0.6"},
+		},
+	}, "")
+
+	result, err := p.Process()
+	assert.NoError(t, err)
+
+	block := result.FormatToHostsfile()
+	assert.Contains(t, block, "\n10.0.0.5 dev.internal\n")
+	assert.Contains(t, block, "\n216.239.38.120 www.google.com\n")
+	assert.NotContains(t, block, "10.0.0.6")
+	assert.Contains(t, block, "\n# domains: 0\n# mappings: 2\n")
+	assert.Empty(t, result.Header().Hosts)
+
+	// Mapped domains aren't counted as blocked ones.
+	assert.Equal(t, 0, result.DomainsCount())
+	assert.Equal(t, 2, result.MappingsCount())
+
+	t.Run("hosts take precedence over blocked domains", func(t *testing.T) {
+		domains := map[string]LineContent{
+			"www.google.com":  {ipAddress: "0.0.0.0", domainName: "www.google.com"},
+			"ads.example.com": {ipAddress: "0.0.0.0", domainName: "ads.example.com"},
+		}
+
+		p.applyHosts(domains, map[string]LineContent{
+			"www.google.com": {ipAddress: "216.239.38.120", domainName: "www.google.com"},
+		})
+
+		assert.NotContains(t, domains, "www.google.com")
+		assert.Contains(t, domains, "ads.example.com")
+	})
+}
+
+func TestProcessContent(t *testing.T) {
+	content := "# comment\n" +
+		"127.0.0.1 localhost\n" +
+		"0.0.0.0 a.com b.com c.com # trackers\n" +
+		"0 d.com\n" +
+		":: e.com\n" +
+		"93.184.216.34 example.com\n" +
+		"2606:2800:220:1::1 example.org\n" +
+		"f.com g.com\n" +
+		"0.0.0.0 not_a_domain\n" +
+		"h.com\n" +
+		"||i.com^\n"
+
+	result, err := (&Processor{}).processContent(content, config.Domainlist{})
+	require.NoError(t, err)
+
+	domains := make([]string, 0, len(result.linesContent))
+	for domain := range result.linesContent {
+		domains = append(domains, domain)
+	}
+
+	// A single adblock rule doesn't make the hosts list an adblock one.
+	assert.ElementsMatch(t, []string{"a.com", "b.com", "c.com", "d.com", "e.com", "h.com"}, domains)
+	assert.Equal(t, parser.FormatHosts, result.Format)
+	assert.Equal(t, 6, result.DomainsCount)
+	assert.Equal(t, map[parser.SkipReason]int{
+		parser.SkipRealIP:          2,
+		parser.SkipUnsupportedLine: 1,
+		parser.SkipReservedDomain:  1,
+		parser.SkipInvalidDomain:   2,
+	}, result.Skipped)
+
+	t.Run("exceptions", func(t *testing.T) {
+		content := "||cdn.example.com^\n" +
+			"@@||cdn.example.com^\n" +
+			"||metrics.example.com^$important\n" +
+			"@@||metrics.example.com^\n"
+
+		p := &Processor{}
+
+		result, err := p.processContent(content, config.Domainlist{Format: parser.FormatAdblock})
+		require.NoError(t, err)
+
+		result.applyExceptions()
+
+		assert.NotContains(t, result.linesContent, "cdn.example.com")
+		assert.Contains(t, result.linesContent, "metrics.example.com")
+		assert.Equal(t, 1, result.DomainsCount)
+
+		// Exceptions of a blocklist don't unblock domains of other blocklists.
+		other, err := p.processContent("cdn.example.com\n", config.Domainlist{})
+		require.NoError(t, err)
+
+		blocklistDomains := p.targetDomains([]TargetResult{result, other})
+		whitelistDomains := make(map[string]LineContent)
+		p.applyExceptions(whitelistDomains, []TargetResult{result, other}, nil)
+		p.applyWhitelist(blocklistDomains, whitelistDomains, nil)
+
+		assert.Contains(t, blocklistDomains, "cdn.example.com")
+
+		// Exceptions of whitelists apply to all blocklists.
+		whitelist, err := p.processContent("@@||cdn.example.com^\n@@||metrics.example.com^\n", config.Domainlist{Format: parser.FormatAdblock})
+		require.NoError(t, err)
+
+		p.applyExceptions(whitelistDomains, []TargetResult{result, other}, []TargetResult{whitelist})
+		p.applyWhitelist(blocklistDomains, whitelistDomains, nil)
+
+		assert.NotContains(t, blocklistDomains, "cdn.example.com")
+		assert.Contains(t, blocklistDomains, "metrics.example.com")
+	})
+
+	t.Run("csv options", func(t *testing.T) {
+		content := "phish_id,url,verified\n" +
+			"1,https://login.example.com/account,yes\n" +
+			"2,http://93.184.216.34/login.php,yes\n"
+
+		result, err := (&Processor{}).processContent(content, config.Domainlist{
+			CSV: &config.CSV{Column: "url"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, parser.FormatCSV, result.Format)
+		assert.Contains(t, result.linesContent, "login.example.com")
+		assert.Equal(t, map[parser.SkipReason]int{parser.SkipIPHost: 1}, result.Skipped)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := (&Processor{}).processContent(content, config.Domainlist{Format: "bind"})
+		assert.Error(t, err)
+	})
+}
+
+func TestApplyWhitelist(t *testing.T) {
+	blocklistDomains := func() map[string]LineContent {
+		domains := make(map[string]LineContent)
+		for _, domain := range []string{"youtube.com", "www.youtube.com", "s.youtube.com", "ads.example.com", "example.com"} {
+			domains[domain] = LineContent{ipAddress: "0.0.0.0", domainName: domain}
+		}
+
+		return domains
+	}
+
+	p := &Processor{}
+
+	t.Run("exact domains", func(t *testing.T) {
+		whitelist, err := p.processContent("youtube.com\n", config.Domainlist{})
+		require.NoError(t, err)
+
+		domains := blocklistDomains()
+		p.applyWhitelist(domains, whitelist.linesContent, []TargetResult{whitelist})
+
+		assert.NotContains(t, domains, "youtube.com")
+		assert.Contains(t, domains, "www.youtube.com")
+	})
+
+	t.Run("wildcards", func(t *testing.T) {
+		whitelist, err := p.processContent("*.youtube.com\nexample.com\n", config.Domainlist{Format: parser.FormatWildcard})
+		require.NoError(t, err)
+
+		domains := blocklistDomains()
+		p.applyWhitelist(domains, whitelist.linesContent, []TargetResult{whitelist})
+
+		assert.Equal(t, map[string]LineContent{
+			"ads.example.com": {ipAddress: "0.0.0.0", domainName: "ads.example.com"},
+		}, domains)
+	})
+
+	t.Run("wildcards mixed into plain domains", func(t *testing.T) {
+		content := "a.example.org\nb.example.org\nc.example.org\nd.example.org\ne.example.org\n*.youtube.com\n"
+		whitelist, err := p.processContent(content, config.Domainlist{})
+		require.NoError(t, err)
+		require.Equal(t, parser.FormatHosts, whitelist.Format)
+
+		domains := blocklistDomains()
+		p.applyWhitelist(domains, whitelist.linesContent, []TargetResult{whitelist})
+
+		assert.NotContains(t, domains, "youtube.com")
+		assert.NotContains(t, domains, "www.youtube.com")
+		assert.NotContains(t, domains, "s.youtube.com")
+		assert.Contains(t, domains, "example.com")
+	})
+
+	t.Run("subdomains option", func(t *testing.T) {
+		whitelist, err := p.processContent("example.com\n", config.Domainlist{Subdomains: true})
+		require.NoError(t, err)
+
+		domains := blocklistDomains()
+		p.applyWhitelist(domains, whitelist.linesContent, []TargetResult{whitelist})
+
+		assert.NotContains(t, domains, "example.com")
+		assert.NotContains(t, domains, "ads.example.com")
+		assert.Contains(t, domains, "youtube.com")
+	})
+}
+
+func TestApplyRegexRules(t *testing.T) {
+	corpus := filepath.Join(t.TempDir(), "queries.txt")
+	err := os.WriteFile(corpus, []byte("ad1.example.net\nad2.example.net\nstats.g.doubleclick.net\nexample.net\n"), 0o644)
+	require.NoError(t, err)
+
+	p := &Processor{loader: source.New(nil, ""), config: &config.Config{
+		Regex: config.Regex{
+			Allow:  []string{`^ad2\.`},
+			Block:  []string{`^ad[0-9]+\.example\.net$`},
+			Corpus: []string{corpus},
+		},
+	}}
+
+	regexBlocklist, err := p.processContent(`.*\.doubleclick\..*`, config.Domainlist{Format: parser.FormatRegex})
+	require.NoError(t, err)
+
+	domains := map[string]LineContent{
+		"ads.example.com": {ipAddress: "0.0.0.0", domainName: "ads.example.com"},
+		"ad2.example.com": {ipAddress: "0.0.0.0", domainName: "ad2.example.com"},
+	}
+
+	p.applyRegexBlock(domains, []TargetResult{regexBlocklist})
+
+	assert.Equal(t, map[string]LineContent{
+		"ads.example.com":         {ipAddress: "0.0.0.0", domainName: "ads.example.com"},
+		"ad2.example.com":         {ipAddress: "0.0.0.0", domainName: "ad2.example.com"},
+		"ad1.example.net":         {ipAddress: config.DefaultSink, domainName: "ad1.example.net"},
+		"ad2.example.net":         {ipAddress: config.DefaultSink, domainName: "ad2.example.net"},
+		"stats.g.doubleclick.net": {ipAddress: config.DefaultSink, domainName: "stats.g.doubleclick.net"},
+	}, domains)
+
+	regexWhitelist, err := p.processContent(`^stats\.`, config.Domainlist{Format: parser.FormatRegex})
+	require.NoError(t, err)
+
+	p.applyRegexAllow(domains, []TargetResult{regexWhitelist})
+
+	assert.Equal(t, map[string]LineContent{
+		"ads.example.com": {ipAddress: "0.0.0.0", domainName: "ads.example.com"},
+		"ad1.example.net": {ipAddress: config.DefaultSink, domainName: "ad1.example.net"},
+	}, domains)
+}
+
+func TestProcessInlineDomains(t *testing.T) {
+	p := &Processor{config: &config.Config{}}
+
+	result, err := p.processWhitelist(config.Domainlist{
+		Domains: []string{"cdn.example.com", "*.youtube.com", "localhost", "not a domain"},
+	})
+	require.NoError(t, err)
+
+	assert.Empty(t, result.Target)
+	assert.Equal(t, 2, result.DomainsCount)
+	assert.Contains(t, result.linesContent, "cdn.example.com")
+	assert.Contains(t, result.wildcards, "youtube.com")
+	assert.Equal(t, map[parser.SkipReason]int{
+		parser.SkipReservedDomain:  1,
+		parser.SkipUnsupportedLine: 1,
+	}, result.Skipped)
+}
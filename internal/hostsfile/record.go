@@ -0,0 +1,123 @@
+package hostsfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WIttyJudge/adless/internal/state"
+	"github.com/WIttyJudge/adless/pkg/fsutil"
+)
+
+var ErrNoRecord = errors.New("hosts file has never been modified by adless")
+
+// Drift describes whether the block in the hosts file is the same as
+// the one adless wrote last time.
+type Drift string
+
+const (
+	DriftNone    Drift = "none"
+	DriftChanged Drift = "changed"
+	DriftMissing Drift = "missing"
+	DriftUnknown Drift = "unknown"
+)
+
+// Record describes the block adless wrote to the hosts file last time.
+type Record struct {
+	GeneratedAt  time.Time `json:"generatedAt"`
+	DomainsCount int       `json:"domainsCount"`
+	Checksum     string    `json:"checksum"`
+	Blocklists   []Source  `json:"blocklists"`
+	Whitelists   []Source  `json:"whitelists"`
+	Hosts        []Source  `json:"hosts,omitempty"`
+}
+
+// NewRecord returns the record of the result written as the block.
+func NewRecord(result Result, block string) Record {
+	return Record{
+		GeneratedAt:  result.header.GeneratedAt,
+		DomainsCount: result.DomainsCount(),
+		Checksum:     checksum(block),
+		Blocklists:   result.header.Blocklists,
+		Whitelists:   result.header.Whitelists,
+		Hosts:        result.header.Hosts,
+	}
+}
+
+// SaveRecord saves the record of the block written to the hosts file.
+func (f *File) SaveRecord(record Record) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.recordLocation()), 0o755); err != nil {
+		return err
+	}
+
+	return fsutil.WriteFile(f.recordLocation(), data)
+}
+
+// Record returns the record of the block written to the hosts file last time.
+func (f *File) Record() (Record, error) {
+	data, err := os.ReadFile(f.recordLocation())
+	if errors.Is(err, os.ErrNotExist) {
+		return Record{}, ErrNoRecord
+	}
+	if err != nil {
+		return Record{}, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Record{}, fmt.Errorf("failed to read record: %w", err)
+	}
+
+	return record, nil
+}
+
+// Drift compares the block in content with the one adless wrote last time.
+func (f *File) Drift(content string) Drift {
+	record, err := f.Record()
+	if err != nil {
+		return DriftUnknown
+	}
+
+	block := Block(content)
+	if block == "" {
+		return DriftMissing
+	}
+
+	if checksum(block) != record.Checksum {
+		return DriftChanged
+	}
+
+	return DriftNone
+}
+
+// Block returns the block of blocked domains from content including
+// StartTag and EndTag, or an empty string if there is no block.
+// Line endings of the block are normalized to "\n".
+func Block(content string) string {
+	blocks := findBlocks(content)
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	return strings.ReplaceAll(content[blocks[0].start:blocks[0].end], "\r\n", "\n")
+}
+
+func (f *File) recordLocation() string {
+	return filepath.Join(state.Dir(), "records", state.Key(f.fileLocation)+".json")
+}
+
+func checksum(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
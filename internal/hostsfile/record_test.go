@@ -0,0 +1,34 @@
+package hostsfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrift(t *testing.T) {
+	block := StartTag + DescriptionComment + "127.0.0.1 example.com\n" + EndTag
+
+	t.Run("unknown if hosts file has never been modified", func(t *testing.T) {
+		hosts := newTestFile(t, testHostsContent+block)
+
+		assert.Equal(t, DriftUnknown, hosts.Drift(hosts.Read()))
+	})
+
+	t.Run("detects changes of the block", func(t *testing.T) {
+		hosts := newTestFile(t, testHostsContent+block)
+		require.NoError(t, hosts.SaveRecord(NewRecord(Result{}, block)))
+
+		assert.Equal(t, DriftNone, hosts.Drift(testHostsContent+block+"\n1.1.1.1 example.org\n"))
+		assert.Equal(t, DriftChanged, hosts.Drift(testHostsContent+StartTag+EndTag))
+		assert.Equal(t, DriftMissing, hosts.Drift(testHostsContent))
+	})
+}
+
+func TestBlock(t *testing.T) {
+	block := StartTag + DescriptionComment + EndTag
+
+	assert.Equal(t, block, Block(testHostsContent+block+"\n"))
+	assert.Empty(t, Block(testHostsContent))
+}
@@ -0,0 +1,66 @@
+package hostsfile
+
+import "strings"
+
+// domainTrie is a trie of domains by their labels in reversed order,
+// so checking if a domain is a subdomain of any domain of the trie takes
+// the number of its labels, no matter how many domains the trie has.
+type domainTrie struct {
+	children map[string]*domainTrie
+	// terminal is set if the path to the node is a domain of the trie.
+	terminal bool
+}
+
+func newDomainTrie() *domainTrie {
+	return &domainTrie{}
+}
+
+// add adds the domain to the trie.
+func (t *domainTrie) add(domain string) {
+	node := t
+	labels := strings.Split(domain, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		if node.children == nil {
+			node.children = make(map[string]*domainTrie)
+		}
+
+		child, ok := node.children[labels[i]]
+		if !ok {
+			child = &domainTrie{}
+			node.children[labels[i]] = child
+		}
+		node = child
+	}
+
+	node.terminal = true
+}
+
+// match checks if the domain or any of its parent domains is in the trie.
+func (t *domainTrie) match(domain string) bool {
+	node := t
+	for domain != "" {
+		label := domain
+		if i := strings.LastIndexByte(domain, '.'); i != -1 {
+			label, domain = domain[i+1:], domain[:i]
+		} else {
+			domain = ""
+		}
+
+		child, ok := node.children[label]
+		if !ok {
+			return false
+		}
+
+		if child.terminal {
+			return true
+		}
+		node = child
+	}
+
+	return false
+}
+
+// empty checks if there are no domains in the trie.
+func (t *domainTrie) empty() bool {
+	return len(t.children) == 0
+}
@@ -0,0 +1,38 @@
+package hostsfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainTrie(t *testing.T) {
+	trie := newDomainTrie()
+	assert.True(t, trie.empty())
+
+	trie.add("youtube.com")
+	trie.add("cdn.example.org")
+	assert.False(t, trie.empty())
+
+	tests := []struct {
+		domain   string
+		expected bool
+	}{
+		{domain: "youtube.com", expected: true},
+		{domain: "www.youtube.com", expected: true},
+		{domain: "a.b.s.youtube.com", expected: true},
+		{domain: "cdn.example.org", expected: true},
+		{domain: "img.cdn.example.org", expected: true},
+		{domain: "example.org", expected: false},
+		{domain: "ads.example.org", expected: false},
+		{domain: "notyoutube.com", expected: false},
+		{domain: "youtube.com.evil.net", expected: false},
+		{domain: "com", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			assert.Equal(t, tt.expected, trie.match(tt.domain))
+		})
+	}
+}
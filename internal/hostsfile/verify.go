@@ -0,0 +1,70 @@
+package hostsfile
+
+import "strings"
+
+// Integrity is a result of the block verification.
+type Integrity string
+
+const (
+	// IntegrityIntact means the block is exactly the same as adless wrote it.
+	IntegrityIntact Integrity = "intact"
+	// IntegrityModified means entries of the block were changed.
+	IntegrityModified Integrity = "modified"
+	// IntegrityTruncated means the block lost its end or some of its entries.
+	IntegrityTruncated Integrity = "truncated"
+	// IntegrityDuplicated means there is more than one block.
+	IntegrityDuplicated Integrity = "duplicated"
+	// IntegrityMissing means there is no block at all.
+	IntegrityMissing Integrity = "missing"
+	// IntegrityUnverifiable means the block has no checksum to verify it.
+	IntegrityUnverifiable Integrity = "unverifiable"
+)
+
+// Verify checks integrity of the block in content using the checksum
+// written to its header.
+func Verify(content string) Integrity {
+	blocks := findBlocks(content)
+
+	switch {
+	case len(blocks) == 0:
+		return IntegrityMissing
+	case len(blocks) > 1:
+		return IntegrityDuplicated
+	case !blocks[0].terminated:
+		return IntegrityTruncated
+	}
+
+	block := Block(content)
+
+	header, err := ParseHeader(block)
+	if err != nil || header.Checksum == "" {
+		return IntegrityUnverifiable
+	}
+
+	if checksum(blockEntries(block)) == header.Checksum {
+		return IntegrityIntact
+	}
+
+	if len(Domains(block)) < header.DomainsCount+header.MappingsCount {
+		return IntegrityTruncated
+	}
+
+	return IntegrityModified
+}
+
+// blockEntries returns the part of the block between its header and EndTag.
+func blockEntries(block string) string {
+	entries := strings.TrimPrefix(block, StartTag)
+	entries = strings.TrimSuffix(entries, EndTag)
+
+	for strings.HasPrefix(entries, "#") {
+		lineEnd := strings.IndexByte(entries, '\n')
+		if lineEnd == -1 {
+			return ""
+		}
+
+		entries = entries[lineEnd+1:]
+	}
+
+	return entries
+}
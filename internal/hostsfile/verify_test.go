@@ -0,0 +1,80 @@
+package hostsfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WIttyJudge/adless/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBlock() string {
+	result := Result{
+		startTag:           StartTag,
+		endTag:             EndTag,
+		descriptionComment: DescriptionComment,
+		domains: map[string]LineContent{
+			"example.com": {ipAddress: config.DefaultSink, domainName: "example.com"},
+			"example.org": {ipAddress: config.DefaultSink, domainName: "example.org"},
+		},
+	}
+
+	return result.FormatToHostsfile()
+}
+
+func TestVerify(t *testing.T) {
+	block := testBlock()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected Integrity
+	}{
+		{
+			name:     "intact",
+			content:  testHostsContent + block,
+			expected: IntegrityIntact,
+		},
+		{
+			name:     "intact with user entries after the block",
+			content:  testHostsContent + block + "\n10.0.0.1 dev.local\n",
+			expected: IntegrityIntact,
+		},
+		{
+			name:     "modified",
+			content:  testHostsContent + strings.Replace(block, "example.com", "example.net", 1),
+			expected: IntegrityModified,
+		},
+		{
+			name:     "entries removed",
+			content:  testHostsContent + strings.Replace(block, "127.0.0.1 example.com\n", "", 1),
+			expected: IntegrityTruncated,
+		},
+		{
+			name:     "end tag lost",
+			content:  testHostsContent + strings.TrimSuffix(block, EndTag),
+			expected: IntegrityTruncated,
+		},
+		{
+			name:     "duplicated",
+			content:  testHostsContent + block + "\n" + block,
+			expected: IntegrityDuplicated,
+		},
+		{
+			name:     "missing",
+			content:  testHostsContent,
+			expected: IntegrityMissing,
+		},
+		{
+			name:     "written without checksum",
+			content:  testHostsContent + StartTag + DescriptionComment + "127.0.0.1 example.com\n" + EndTag,
+			expected: IntegrityUnverifiable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Verify(tt.content))
+		})
+	}
+}
@@ -1,7 +1,7 @@
 package http
 
 import (
-	"io"
+	"fmt"
 	"net"
 	"net/http"
 	"time"
@@ -36,18 +36,18 @@ func New() *HTTP {
 	}
 }
 
-func (h *HTTP) Get(url string) (string, error) {
+// Open sends GET request to the url and returns the response, so its body
+// can be read as a stream. The body must be closed by the caller.
+func (h *HTTP) Open(url string) (*http.Response, error) {
 	resp, err := h.client.Get(url)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
 	}
 
-	return string(body), nil
+	return resp, nil
 }
@@ -0,0 +1,100 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryInterval is how often the lock is retried while waiting for it.
+const retryInterval = 100 * time.Millisecond
+
+// WaitForever can be passed as a timeout to wait until the lock is released.
+const WaitForever time.Duration = -1
+
+var errWouldBlock = errors.New("lock is held by another process")
+
+// LockedError is returned when the lock is held by another process.
+type LockedError struct {
+	Path string
+	PID  int
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("another adless process is running, lock file: %s", e.Path)
+	}
+
+	return fmt.Sprintf("another adless process is running (pid %d), lock file: %s", e.PID, e.Path)
+}
+
+// Lock is an exclusive advisory lock between adless processes.
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// Acquire acquires the lock file at path.
+// If the lock is held by another process, it retries until timeout expires.
+// Zero timeout means to fail immediately, WaitForever means to never give up.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		file, err := tryLock(path)
+		if err == nil {
+			lock := &Lock{path: path, file: file}
+			if err := lock.writePID(); err != nil {
+				lock.Release()
+				return nil, err
+			}
+
+			return lock, nil
+		}
+
+		if !errors.Is(err, errWouldBlock) {
+			return nil, err
+		}
+
+		if timeout != WaitForever && !time.Now().Before(deadline) {
+			return nil, &LockedError{Path: path, PID: holderPID(path)}
+		}
+
+		time.Sleep(retryInterval)
+	}
+}
+
+// Release releases the lock.
+func (l *Lock) Release() {
+	unlock(l.path, l.file)
+}
+
+func (l *Lock) writePID() error {
+	if err := l.file.Truncate(0); err != nil {
+		return err
+	}
+
+	_, err := l.file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+
+	return err
+}
+
+// holderPID returns PID of the process holding the lock or 0 if it's unknown.
+func holderPID(path string) int {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(content)))
+
+	return pid
+}
@@ -0,0 +1,42 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package lock
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock creates the lock file exclusively, since flock isn't available.
+// The lock file left behind by a process that doesn't exist anymore is
+// considered stale and is taken over.
+func tryLock(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err == nil {
+		return file, nil
+	}
+
+	if !errors.Is(err, os.ErrExist) {
+		return nil, err
+	}
+
+	if pid := holderPID(path); pid != 0 && processExists(pid) {
+		return nil, errWouldBlock
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, errWouldBlock
+	}
+
+	return file, err
+}
+
+func unlock(path string, file *os.File) {
+	file.Close()
+	os.Remove(path)
+}
@@ -0,0 +1,56 @@
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	t.Run("returns error naming the holder of the lock", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state", "adless.lock")
+
+		lock, err := Acquire(path, 0)
+		require.NoError(t, err)
+		defer lock.Release()
+
+		_, err = Acquire(path, 0)
+
+		var lockedErr *LockedError
+		require.ErrorAs(t, err, &lockedErr)
+		assert.Equal(t, os.Getpid(), lockedErr.PID)
+		assert.Contains(t, err.Error(), path)
+	})
+
+	t.Run("waits until the lock is released", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "adless.lock")
+
+		lock, err := Acquire(path, 0)
+		require.NoError(t, err)
+
+		go func() {
+			time.Sleep(3 * retryInterval)
+			lock.Release()
+		}()
+
+		secondLock, err := Acquire(path, 5*time.Second)
+		require.NoError(t, err)
+		secondLock.Release()
+	})
+
+	t.Run("can be acquired again after release", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "adless.lock")
+
+		lock, err := Acquire(path, 0)
+		require.NoError(t, err)
+		lock.Release()
+
+		lock, err = Acquire(path, 0)
+		require.NoError(t, err)
+		lock.Release()
+	})
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package lock
+
+import "os"
+
+// processExists checks if the process with the PID is running.
+// FindProcess fails if there is no such process on Windows.
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	process.Release()
+
+	return true
+}
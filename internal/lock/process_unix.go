@@ -0,0 +1,23 @@
+//go:build unix && !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// processExists checks if the process with the PID is running.
+// FindProcess always succeeds on Unix, so signal 0 is sent to the process.
+func processExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	defer process.Release()
+
+	err = process.Signal(syscall.Signal(0))
+
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,239 @@
+package source
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// compression is a compression format of lists.
+type compression string
+
+const (
+	compressionNone  compression = ""
+	compressionGzip  compression = "gzip"
+	compressionZip   compression = "zip"
+	compressionBzip2 compression = "bzip2"
+)
+
+// maxContentSize limits the size of the content read from a list,
+// so a small compressed list can't exhaust the memory once decompressed.
+var maxContentSize int64 = 512 << 20
+
+// magicNumbers are the first bytes of compressed content.
+var magicNumbers = []struct {
+	compression compression
+	magic       string
+}{
+	{compression: compressionGzip, magic: "\x1f\x8b"},
+	{compression: compressionZip, magic: "PK\x03\x04"},
+	{compression: compressionBzip2, magic: "BZh"},
+}
+
+// hints describe the content besides its bytes.
+type hints struct {
+	// encoding is Content-Encoding header of the response.
+	encoding string
+	// contentType is Content-Type header of the response.
+	contentType string
+	// name is the path of the file or URL.
+	name string
+}
+
+// compression returns the compression the hints point to.
+func (h hints) compression() compression {
+	switch strings.ToLower(h.encoding) {
+	case "gzip", "x-gzip":
+		return compressionGzip
+	case "bzip2", "x-bzip2":
+		return compressionBzip2
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(h.contentType)
+	switch mediaType {
+	case "application/gzip", "application/x-gzip":
+		return compressionGzip
+	case "application/zip", "application/x-zip-compressed":
+		return compressionZip
+	case "application/x-bzip2":
+		return compressionBzip2
+	}
+
+	switch strings.ToLower(path.Ext(h.name)) {
+	case ".gz", ".gzip":
+		return compressionGzip
+	case ".zip":
+		return compressionZip
+	case ".bz2":
+		return compressionBzip2
+	}
+
+	return compressionNone
+}
+
+// detectCompression returns the compression of the content by its magic
+// bytes. The hints are used only if there are no bytes to check, as
+// servers and file names often point to a compression that isn't there.
+func detectCompression(magic []byte, h hints) compression {
+	if len(magic) == 0 {
+		return h.compression()
+	}
+
+	for _, number := range magicNumbers {
+		if strings.HasPrefix(string(magic), number.magic) {
+			return number.compression
+		}
+	}
+
+	return compressionNone
+}
+
+// readContent reads the content of the list, decompressing it on the fly,
+// so the whole compressed content is never held in memory. Zip archives need
+// random access, so they're read from the file or spooled to a temporary one.
+// The entry selects files of the zip archive, all of them are read if it's empty.
+func readContent(r io.Reader, h hints, entry string) (string, error) {
+	buffered := bufio.NewReader(r)
+	magic, _ := buffered.Peek(4)
+
+	switch detectCompression(magic, h) {
+	case compressionGzip:
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return "", err
+		}
+		defer gzipReader.Close()
+
+		return readAll(gzipReader)
+	case compressionBzip2:
+		return readAll(bzip2.NewReader(buffered))
+	case compressionZip:
+		return readZip(r, buffered, entry)
+	}
+
+	return readAll(buffered)
+}
+
+// readZip reads files of the zip archive matching the entry.
+func readZip(src io.Reader, buffered io.Reader, entry string) (string, error) {
+	file, ok := src.(*os.File)
+	if ok {
+		if info, err := file.Stat(); err != nil || !info.Mode().IsRegular() {
+			ok = false
+		}
+	}
+
+	if !ok {
+		tmpFile, err := os.CreateTemp("", "adless-*.zip")
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(tmpFile.Name())
+		defer tmpFile.Close()
+
+		if _, err := io.Copy(tmpFile, buffered); err != nil {
+			return "", err
+		}
+		file = tmpFile
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	archive, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return "", err
+	}
+
+	files := slices.DeleteFunc(slices.Clone(archive.File), func(f *zip.File) bool {
+		return f.FileInfo().IsDir() || !matchEntry(entry, f.Name)
+	})
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files matching %q in zip archive", entry)
+	}
+
+	slices.SortFunc(files, func(a, b *zip.File) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var builder strings.Builder
+	for _, f := range files {
+		if err := appendZipFile(&builder, f); err != nil {
+			return "", err
+		}
+	}
+
+	return builder.String(), nil
+}
+
+func appendZipFile(builder *strings.Builder, f *zip.File) error {
+	reader, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return appendContent(builder, reader)
+}
+
+// matchEntry checks if the file of the zip archive matches the entry,
+// which is a name or a glob pattern of the file or its path in the archive.
+func matchEntry(entry, name string) bool {
+	if entry == "" {
+		return true
+	}
+
+	for _, candidate := range []string{name, path.Base(name)} {
+		if matched, _ := path.Match(entry, candidate); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendContent appends the content to the builder. Contents are separated
+// by line endings, so their last lines aren't merged.
+func appendContent(builder *strings.Builder, r io.Reader) error {
+	if builder.Len() > 0 && !strings.HasSuffix(builder.String(), "\n") {
+		builder.WriteString("\n")
+	}
+
+	return copyLimited(builder, r)
+}
+
+func readAll(r io.Reader) (string, error) {
+	var builder strings.Builder
+	if err := copyLimited(&builder, r); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// copyLimited copies the content to the builder until it reaches
+// maxContentSize, failing if the content is larger.
+func copyLimited(builder *strings.Builder, r io.Reader) error {
+	limit := maxContentSize - int64(builder.Len())
+
+	n, err := io.Copy(builder, io.LimitReader(r, limit+1))
+	if err != nil {
+		return err
+	}
+
+	if n > limit {
+		return fmt.Errorf("content is larger than %d MiB", maxContentSize>>20)
+	}
+
+	return nil
+}
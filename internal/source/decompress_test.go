@@ -0,0 +1,202 @@
+package source
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	adlesshttp "github.com/WIttyJudge/adless/internal/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bzip2Content is "ads.example.com\n" compressed by bzip2.
+const bzip2Content = "425a68393141592653591809f6e5000001d180001000012e06c840200022993036a840d0342e9789ad708483e2ee48a70a1203013edca0"
+
+func gzipData(t *testing.T, content string) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+func zipData(t *testing.T, files map[string]string) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+func TestDetectCompression(t *testing.T) {
+	tests := []struct {
+		name     string
+		magic    []byte
+		hints    hints
+		expected compression
+	}{
+		{name: "gzip magic", magic: []byte{0x1f, 0x8b, 0x08, 0x00}, expected: compressionGzip},
+		{name: "zip magic", magic: []byte("PK\x03\x04"), expected: compressionZip},
+		{name: "bzip2 magic", magic: []byte("BZh9"), expected: compressionBzip2},
+		{name: "plain", magic: []byte("0.0."), hints: hints{name: "/hosts"}, expected: compressionNone},
+		{name: "content encoding", hints: hints{encoding: "x-gzip"}, expected: compressionGzip},
+		{name: "content type", hints: hints{contentType: "application/zip; charset=binary"}, expected: compressionZip},
+		{name: "extension", hints: hints{name: "/lists/hosts.BZ2"}, expected: compressionBzip2},
+		{name: "magic over hints", magic: []byte("PK\x03\x04"), hints: hints{name: "hosts.gz"}, expected: compressionZip},
+		{name: "no magic over hints", magic: []byte("ads."), hints: hints{encoding: "gzip", name: "hosts.gz"}, expected: compressionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, detectCompression(tt.magic, tt.hints))
+		})
+	}
+}
+
+func TestLoadCompressed(t *testing.T) {
+	bzip2Data, err := hex.DecodeString(bzip2Content)
+	require.NoError(t, err)
+
+	archive := zipData(t, map[string]string{
+		"README.md":          "# lists\n",
+		"lists/hosts":        "0.0.0.0 ads.example.com",
+		"lists/domains.txt":  "tracker.example.com\n",
+		"lists/nested/x.txt": "x.example.com\n",
+	})
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"hosts.gz":   gzipData(t, "0.0.0.0 ads.example.com\n"),
+		"hosts.bz2":  bzip2Data,
+		"lists.zip":  archive,
+		"hosts.data": gzipData(t, "0.0.0.0 magic.example.com\n"),
+		"plain.gz":   []byte("ads.example.com\n"),
+		"broken.gz":  []byte("\x1f\x8bads.example.com\n"),
+	}
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o644))
+	}
+
+	loader := New(nil, dir)
+
+	tests := []struct {
+		name     string
+		target   string
+		entry    string
+		expected string
+	}{
+		{name: "gzip", target: "hosts.gz", expected: "0.0.0.0 ads.example.com\n"},
+		{name: "bzip2", target: "hosts.bz2", expected: "ads.example.com\n"},
+		{name: "magic bytes", target: "hosts.data", expected: "0.0.0.0 magic.example.com\n"},
+		{name: "misnamed plain list", target: "plain.gz", expected: "ads.example.com\n"},
+		{name: "zip entry", target: "lists.zip", entry: "lists/domains.txt", expected: "tracker.example.com\n"},
+		{name: "zip entry by name", target: "lists.zip", entry: "hosts", expected: "0.0.0.0 ads.example.com"},
+		{name: "zip entry pattern", target: "lists.zip", entry: "*.txt", expected: "tracker.example.com\nx.example.com\n"},
+		{
+			name:     "zip all files",
+			target:   "lists.zip",
+			expected: "# lists\ntracker.example.com\n0.0.0.0 ads.example.com\nx.example.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := loader.Load(tt.target, tt.entry)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, content)
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := loader.Load("broken.gz", "")
+		assert.Error(t, err)
+
+		_, err = loader.Load("lists.zip", "missing.txt")
+		assert.ErrorContains(t, err, "no files matching")
+	})
+
+	t.Run("limits decompressed size", func(t *testing.T) {
+		defaultSize := maxContentSize
+		maxContentSize = 16
+		defer func() { maxContentSize = defaultSize }()
+
+		_, err := loader.Load("hosts.gz", "")
+		assert.ErrorContains(t, err, "content is larger")
+
+		_, err = loader.Load("lists.zip", "")
+		assert.ErrorContains(t, err, "content is larger")
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		loader := New(nil, dir)
+		loader.stdin = bytes.NewReader(archive)
+
+		content, err := loader.Load(Stdin, "lists/domains.txt")
+		require.NoError(t, err)
+
+		assert.Equal(t, "tracker.example.com\n", content)
+
+		// Every target reading the standard input gets its own entry.
+		content, err = loader.Load(Stdin, "hosts")
+		require.NoError(t, err)
+
+		assert.Equal(t, "0.0.0.0 ads.example.com", content)
+	})
+
+	t.Run("url", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/hosts.gz":
+				w.Header().Set("Content-Type", "application/gzip")
+				_, _ = w.Write(files["hosts.gz"])
+			case "/download":
+				w.Header().Set("Content-Type", "application/zip")
+				_, _ = w.Write(archive)
+			case "/encoded":
+				w.Header().Set("Content-Encoding", "gzip")
+				_, _ = w.Write(gzipData(t, "encoded.example.com\n"))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+
+		loader := New(adlesshttp.New(), "")
+
+		tests := []struct {
+			path     string
+			entry    string
+			expected string
+		}{
+			{path: "/hosts.gz", expected: "0.0.0.0 ads.example.com\n"},
+			{path: "/download", entry: "lists/hosts", expected: "0.0.0.0 ads.example.com"},
+			{path: "/encoded", expected: "encoded.example.com\n"},
+		}
+
+		for _, tt := range tests {
+			content, err := loader.Load(server.URL+tt.path, tt.entry)
+			require.NoError(t, err, tt.path)
+
+			assert.Equal(t, tt.expected, content, tt.path)
+		}
+
+		_, err := loader.Load(server.URL+"/missing.gz", "")
+		assert.ErrorContains(t, err, "404")
+	})
+}
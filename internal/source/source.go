@@ -0,0 +1,204 @@
+// Package source loads content of list targets: remote URLs, local files,
+// directories of list files and the standard input.
+package source
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/WIttyJudge/adless/internal/http"
+)
+
+// Stdin is the target reading the list from the standard input.
+const Stdin = "-"
+
+// Loader loads content of targets.
+type Loader struct {
+	httpClient *http.HTTP
+	// baseDir is the directory relative paths are resolved against.
+	baseDir string
+
+	stdin     io.Reader
+	stdinOnce sync.Once
+	// stdinData is the raw standard input, the entry of every target
+	// is applied to it separately.
+	stdinData []byte
+	stdinErr  error
+}
+
+// New returns a loader resolving relative paths against the base directory.
+// If the base directory is empty, they're resolved against the working one.
+func New(httpClient *http.HTTP, baseDir string) *Loader {
+	return &Loader{
+		httpClient: httpClient,
+		baseDir:    baseDir,
+		stdin:      os.Stdin,
+	}
+}
+
+// IsURL checks if the target is a remote list.
+func IsURL(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+// Load returns content of the target. The target may be:
+//   - http:// or https:// URL of a remote list;
+//   - file:// URL or a path of a local file;
+//   - a directory, all list files of which are loaded;
+//   - a glob pattern, i.e. /etc/adless/lists/*.txt;
+//   - - to read the list from the standard input.
+//
+// Content of several files is joined in the lexical order of their paths.
+// Compressed content (gzip, zip or bzip2) is decompressed, the entry selects
+// files of zip archives.
+func (l *Loader) Load(target, entry string) (string, error) {
+	switch {
+	case target == Stdin:
+		return l.readStdin(entry)
+	case IsURL(target):
+		return l.loadURL(target, entry)
+	case strings.HasPrefix(target, "file://"):
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", err
+		}
+
+		return l.loadPath(filepath.FromSlash(u.Path), entry)
+	}
+
+	return l.loadPath(target, entry)
+}
+
+// loadURL returns content of the remote list.
+func (l *Loader) loadURL(target, entry string) (string, error) {
+	resp, err := l.httpClient.Open(target)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	h := hints{name: resp.Request.URL.Path}
+	// The HTTP client has already decompressed the content
+	// if it was encoded on the fly.
+	if !resp.Uncompressed {
+		h.encoding = resp.Header.Get("Content-Encoding")
+		h.contentType = resp.Header.Get("Content-Type")
+	}
+
+	return readContent(resp.Body, h, entry)
+}
+
+// loadPath returns content of the local file, directory or glob pattern.
+func (l *Loader) loadPath(path, entry string) (string, error) {
+	if !filepath.IsAbs(path) && l.baseDir != "" {
+		path = filepath.Join(l.baseDir, path)
+	}
+
+	paths := []string{path}
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && info.IsDir():
+		if paths, err = listFiles(path); err != nil {
+			return "", err
+		}
+	case errors.Is(err, os.ErrNotExist) && hasMeta(path):
+		if paths, err = filepath.Glob(path); err != nil {
+			return "", err
+		}
+		// Hidden files are skipped only when expanding the pattern,
+		// the explicitly named ones are read.
+		paths = slices.DeleteFunc(paths, isHidden)
+		if len(paths) == 0 {
+			return "", fmt.Errorf("no files match %s", path)
+		}
+	case err != nil:
+		return "", err
+	}
+
+	slices.Sort(paths)
+
+	var builder strings.Builder
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			continue
+		}
+
+		content, err := readFile(path, entry)
+		if err != nil {
+			return "", err
+		}
+
+		if err := appendContent(&builder, strings.NewReader(content)); err != nil {
+			return "", err
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// readFile returns content of the file.
+func readFile(path, entry string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return readContent(file, hints{name: path}, entry)
+}
+
+// listFiles returns paths of the files in the directory, except hidden ones.
+func listFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || isHidden(entry.Name()) {
+			continue
+		}
+
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no list files in %s", dir)
+	}
+
+	return paths, nil
+}
+
+// readStdin reads the standard input once, so it can be used
+// by several targets with different entries.
+func (l *Loader) readStdin(entry string) (string, error) {
+	l.stdinOnce.Do(func() {
+		l.stdinData, l.stdinErr = io.ReadAll(l.stdin)
+	})
+
+	if l.stdinErr != nil {
+		return "", l.stdinErr
+	}
+
+	return readContent(bytes.NewReader(l.stdinData), hints{}, entry)
+}
+
+// isHidden checks if the file is hidden.
+func isHidden(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ".")
+}
+
+// hasMeta checks if the path is a glob pattern.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
@@ -0,0 +1,72 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	listsDir := filepath.Join(dir, "lists")
+	require.NoError(t, os.Mkdir(listsDir, 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(listsDir, "nested"), 0o755))
+
+	files := map[string]string{
+		"custom.txt":        "custom.example.com\n",
+		"lists/b.txt":       "b.example.com\n",
+		"lists/a.txt":       "a.example.com\n",
+		"lists/c.hosts":     "0.0.0.0 c.example.com\n",
+		"lists/.hidden.txt": "hidden.example.com\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	loader := New(nil, dir)
+
+	tests := []struct {
+		name     string
+		target   string
+		expected string
+	}{
+		{name: "absolute path", target: filepath.Join(dir, "custom.txt"), expected: "custom.example.com\n"},
+		{name: "relative path", target: "custom.txt", expected: "custom.example.com\n"},
+		{name: "file url", target: "file://" + filepath.ToSlash(filepath.Join(dir, "custom.txt")), expected: "custom.example.com\n"},
+		{name: "directory", target: listsDir, expected: "a.example.com\nb.example.com\n0.0.0.0 c.example.com\n"},
+		{name: "glob", target: "lists/*.txt", expected: "a.example.com\nb.example.com\n"},
+		{name: "hidden file", target: "lists/.hidden.txt", expected: "hidden.example.com\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := loader.Load(tt.target, "")
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, content)
+		})
+	}
+
+	t.Run("stdin", func(t *testing.T) {
+		loader := New(nil, dir)
+		loader.stdin = strings.NewReader("stdin.example.com\n")
+
+		for range 2 {
+			content, err := loader.Load(Stdin, "")
+			require.NoError(t, err)
+
+			assert.Equal(t, "stdin.example.com\n", content)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, target := range []string{"missing.txt", "lists/*.csv", "lists/nested"} {
+			_, err := loader.Load(target, "")
+			assert.Error(t, err, target)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Dir returns the directory where adless keeps its state, like lock file.
+// It can be redefined by ADLESS_STATE_DIR environment variable.
+func Dir() string {
+	if sd := os.Getenv("ADLESS_STATE_DIR"); sd != "" {
+		return sd
+	}
+
+	if runtime.GOOS == "windows" {
+		if programData := os.Getenv("ProgramData"); programData != "" {
+			return filepath.Join(programData, "adless")
+		}
+
+		return `C:\ProgramData\adless`
+	}
+
+	// The system hosts file can be modified only by root, so the state is
+	// shared between all the users that use sudo.
+	if os.Geteuid() == 0 {
+		return "/var/lib/adless"
+	}
+
+	if xdgState := os.Getenv("XDG_STATE_HOME"); xdgState != "" {
+		return filepath.Join(xdgState, "adless")
+	}
+
+	return filepath.Join(os.Getenv("HOME"), ".local", "state", "adless")
+}
+
+// Key returns a short key that identifies the file at location, so
+// the state of different hosts files never gets mixed.
+func Key(location string) string {
+	if abs, err := filepath.Abs(location); err == nil {
+		location = abs
+	}
+
+	hash := sha256.Sum256([]byte(location))
+
+	return hex.EncodeToString(hash[:])[:12]
+}
@@ -0,0 +1,328 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around changes.
+const DefaultContext = 3
+
+// maxLCSCells limits the size of the table used to compare the regions
+// that have no unique lines in common.
+const maxLCSCells = 1 << 20
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns the difference between old and new contents
+// in the unified format. It returns an empty string if they are equal.
+//
+// The patience algorithm is used: lines that are unique in both contents
+// are matched first, which works well for hosts files where almost every
+// line is unique.
+func Unified(oldName, newName, oldContent, newContent string, context int) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	ops := compare(splitLines(oldContent), splitLines(newContent))
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", oldName, newName)
+	writeHunks(&builder, ops, context)
+
+	return builder.String()
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+func compare(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	ops = appendOps(ops, opEqual, a[:prefix])
+	ops = append(ops, compareMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	ops = appendOps(ops, opEqual, a[len(a)-suffix:])
+
+	return ops
+}
+
+func compareMiddle(a, b []string) []op {
+	if len(a) == 0 || len(b) == 0 {
+		return append(appendOps(nil, opDelete, a), appendOps(nil, opInsert, b)...)
+	}
+
+	anchors := uniqueAnchors(a, b)
+	if len(anchors) == 0 {
+		return compareLCS(a, b)
+	}
+
+	var ops []op
+	prevA, prevB := 0, 0
+	for _, anchor := range anchors {
+		ops = append(ops, compare(a[prevA:anchor[0]], b[prevB:anchor[1]])...)
+		ops = append(ops, op{kind: opEqual, line: a[anchor[0]]})
+		prevA, prevB = anchor[0]+1, anchor[1]+1
+	}
+
+	return append(ops, compare(a[prevA:], b[prevB:])...)
+}
+
+// uniqueAnchors returns positions of lines that occur exactly once in both
+// a and b, reduced to the longest sequence that keeps the same order.
+func uniqueAnchors(a, b []string) [][2]int {
+	type occurrence struct {
+		countA, countB int
+		indexA, indexB int
+	}
+
+	occurrences := make(map[string]*occurrence)
+	for i, line := range a {
+		o, ok := occurrences[line]
+		if !ok {
+			o = &occurrence{}
+			occurrences[line] = o
+		}
+		o.countA++
+		o.indexA = i
+	}
+
+	for i, line := range b {
+		if o, ok := occurrences[line]; ok {
+			o.countB++
+			o.indexB = i
+		}
+	}
+
+	var pairs [][2]int
+	for _, o := range occurrences {
+		if o.countA == 1 && o.countB == 1 {
+			pairs = append(pairs, [2]int{o.indexA, o.indexB})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+
+	return longestIncreasing(pairs)
+}
+
+// longestIncreasing returns the longest subsequence of pairs
+// with increasing positions in b. Pairs are sorted by positions in a.
+func longestIncreasing(pairs [][2]int) [][2]int {
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	// tails[i] is the index of the pair that ends the subsequence of length i+1.
+	tails := make([]int, 0, len(pairs))
+	prev := make([]int, len(pairs))
+
+	for i, pair := range pairs {
+		n := sort.Search(len(tails), func(j int) bool {
+			return pairs[tails[j]][1] >= pair[1]
+		})
+
+		if n > 0 {
+			prev[i] = tails[n-1]
+		} else {
+			prev[i] = -1
+		}
+
+		if n == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[n] = i
+		}
+	}
+
+	result := make([][2]int, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, prev[k] {
+		result[i] = pairs[k]
+	}
+
+	return result
+}
+
+// compareLCS compares a and b using the longest common subsequence table.
+// If the table would be too big, all the lines are considered as changed.
+func compareLCS(a, b []string) []op {
+	if len(a)*len(b) > maxLCSCells {
+		return append(appendOps(nil, opDelete, a), appendOps(nil, opInsert, b)...)
+	}
+
+	// lengths[i][j] is the length of LCS of a[i:] and b[j:].
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, line: a[i]})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			ops = append(ops, op{kind: opDelete, line: a[i]})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, line: b[j]})
+			j++
+		}
+	}
+
+	ops = appendOps(ops, opDelete, a[i:])
+
+	return appendOps(ops, opInsert, b[j:])
+}
+
+func appendOps(ops []op, kind opKind, lines []string) []op {
+	for _, line := range lines {
+		ops = append(ops, op{kind: kind, line: line})
+	}
+
+	return ops
+}
+
+func writeHunks(builder *strings.Builder, ops []op, context int) {
+	// Line numbers of ops[counted] in the old and new contents.
+	counted, oldLine, newLine := 0, 1, 1
+
+	for start := 0; start < len(ops); {
+		// Skips unchanged lines until the next change.
+		for start < len(ops) && ops[start].kind == opEqual {
+			start++
+		}
+		if start == len(ops) {
+			return
+		}
+
+		hunkStart := max(start-context, 0)
+
+		// The hunk continues while changes are separated by less than
+		// two contexts of unchanged lines.
+		end := start
+		for end < len(ops) {
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual {
+				next++
+			}
+
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+
+			for next < len(ops) && ops[next].kind != opEqual {
+				next++
+			}
+			end = next
+		}
+
+		hunkEnd := min(end+context, len(ops))
+
+		for ; counted < hunkStart; counted++ {
+			oldLine, newLine = advance(ops[counted], oldLine, newLine)
+		}
+
+		writeHunk(builder, ops[hunkStart:hunkEnd], oldLine, newLine)
+
+		start = hunkEnd
+	}
+}
+
+// advance returns line numbers of the next op.
+func advance(o op, oldLine, newLine int) (int, int) {
+	if o.kind != opInsert {
+		oldLine++
+	}
+	if o.kind != opDelete {
+		newLine++
+	}
+
+	return oldLine, newLine
+}
+
+func writeHunk(builder *strings.Builder, ops []op, oldLine, newLine int) {
+	oldCount, newCount := 0, 0
+	for _, o := range ops {
+		oldCount, newCount = advance(o, oldCount, newCount)
+	}
+
+	fmt.Fprintf(builder, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+
+	for _, o := range ops {
+		prefix := " "
+		switch o.kind {
+		case opDelete:
+			prefix = "-"
+		case opInsert:
+			prefix = "+"
+		case opEqual:
+		}
+
+		builder.WriteString(prefix + o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			builder.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the range of lines the way diff tools do:
+// an empty range points to the line before it.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+
+	return fmt.Sprintf("%d,%d", line, count)
+}
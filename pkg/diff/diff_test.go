@@ -0,0 +1,87 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	t.Run("returns empty string if contents are equal", func(t *testing.T) {
+		assert.Empty(t, Unified("a", "b", "line\n", "line\n", DefaultContext))
+	})
+
+	t.Run("shows added and removed lines with context", func(t *testing.T) {
+		oldContent := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+		newContent := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n"
+
+		expected := "" +
+			"--- old\n" +
+			"+++ new\n" +
+			"@@ -2,8 +2,9 @@\n" +
+			" 2\n" +
+			" 3\n" +
+			" 4\n" +
+			"-5\n" +
+			"+five\n" +
+			" 6\n" +
+			" 7\n" +
+			" 8\n" +
+			" 9\n" +
+			"+10\n"
+
+		assert.Equal(t, expected, Unified("old", "new", oldContent, newContent, DefaultContext))
+	})
+
+	t.Run("splits distant changes into separate hunks", func(t *testing.T) {
+		oldContent := "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n"
+		newContent := "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n"
+
+		expected := "" +
+			"--- old\n" +
+			"+++ new\n" +
+			"@@ -1,4 +1,4 @@\n" +
+			"-a\n" +
+			"+A\n" +
+			" 1\n" +
+			" 2\n" +
+			" 3\n" +
+			"@@ -7,4 +7,4 @@\n" +
+			" 6\n" +
+			" 7\n" +
+			" 8\n" +
+			"-b\n" +
+			"+B\n"
+
+		assert.Equal(t, expected, Unified("old", "new", oldContent, newContent, DefaultContext))
+	})
+
+	t.Run("matches moved unique lines", func(t *testing.T) {
+		oldContent := "x\na\nb\nc\n"
+		newContent := "a\nb\nx\nc\n"
+
+		expected := "" +
+			"--- old\n" +
+			"+++ new\n" +
+			"@@ -1,4 +1,4 @@\n" +
+			"-x\n" +
+			" a\n" +
+			" b\n" +
+			"+x\n" +
+			" c\n"
+
+		assert.Equal(t, expected, Unified("old", "new", oldContent, newContent, DefaultContext))
+	})
+
+	t.Run("marks missing newline at the end of file", func(t *testing.T) {
+		expected := "" +
+			"--- old\n" +
+			"+++ new\n" +
+			"@@ -1 +1 @@\n" +
+			"-a\n" +
+			"\\ No newline at end of file\n" +
+			"+a\n"
+
+		assert.Equal(t, expected, Unified("old", "new", "a", "a\n", DefaultContext))
+	})
+}
@@ -1,13 +1,22 @@
 package fsutil
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"syscall"
 )
 
+// DefaultFileMode is used for files that don't exist before they are written.
+const DefaultFileMode fs.FileMode = 0o644
+
 // CopyFile copies a file from src to dst.
+// The copy is written atomically, so dst is either fully replaced or
+// left untouched.
 func CopyFile(src, dst string) error {
 	srcFile, err := os.Open(src)
 	if errors.Is(err, os.ErrNotExist) {
@@ -19,15 +28,136 @@ func CopyFile(src, dst string) error {
 
 	defer srcFile.Close()
 
-	dstFile, err := os.Create(dst)
+	return writeAtomic(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, srcFile)
+		return err
+	})
+}
+
+// WriteFile atomically replaces the content of the file.
+// The data is written to a temporary file in the same directory, synced to
+// the disk and then renamed over the original file, so a crash or a failed
+// write never leaves the file half-written. Mode and ownership of the
+// original file are preserved. If the file is a symlink, its target is replaced.
+func WriteFile(name string, data []byte) error {
+	return writeAtomic(name, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+func writeAtomic(name string, write func(w io.Writer) error) error {
+	mode := DefaultFileMode
+
+	// The symlinked file (i.e /etc/hosts on some distributions) is replaced
+	// at its target, so the link itself is kept.
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	info, err := os.Stat(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
 	if err != nil {
 		return err
 	}
-	defer dstFile.Close()
+	tmpName := tmpFile.Name()
+
+	// Removing the temporary file is a no-op once it has been renamed.
+	defer os.Remove(tmpName)
+
+	if err := write(tmpFile); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return err
+	}
+
+	if info != nil {
+		if err := chown(tmpName, info); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpName, name); err != nil {
+		// Files that are bind mounted (i.e /etc/hosts inside of a Docker
+		// container) can't be replaced, so the content is copied over instead.
+		// It isn't atomic, but the original content is restored if copying fails.
+		if errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EXDEV) {
+			return copyInPlace(tmpName, name)
+		}
+
+		return err
+	}
+
+	return syncDir(filepath.Dir(name))
+}
+
+// copyInPlace copies content of src over dst without replacing dst.
+func copyInPlace(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	return overwrite(dst, srcFile)
+}
+
+// overwrite truncates the file and writes content to it. The original
+// content is kept in memory and written back if the write fails, so the file
+// isn't left truncated.
+func overwrite(name string, content io.Reader) error {
+	original, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	if err := writeInPlace(name, content); err != nil {
+		if restoreErr := writeInPlace(name, bytes.NewReader(original)); restoreErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to restore %s: %w", name, restoreErr))
+		}
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func writeInPlace(name string, content io.Reader) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package fsutil
+
+import "os"
+
+func chown(_ string, _ os.FileInfo) error {
+	return nil
+}
+
+func syncDir(_ string) error {
+	return nil
+}
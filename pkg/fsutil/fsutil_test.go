@@ -1,7 +1,10 @@
 package fsutil
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -54,3 +57,112 @@ func TestCopyFile(t *testing.T) {
 		assert.EqualValues(t, dstContent, srcContent)
 	})
 }
+
+func TestWriteFile(t *testing.T) {
+	td := t.TempDir()
+
+	t.Run("creates file if it doesn't exist", func(t *testing.T) {
+		name := filepath.Join(td, "new")
+
+		require.NoError(t, WriteFile(name, []byte("content")))
+
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(content))
+	})
+
+	t.Run("replaces content and preserves file mode", func(t *testing.T) {
+		name := filepath.Join(td, "existing")
+		require.NoError(t, os.WriteFile(name, []byte("old content"), 0o600))
+		require.NoError(t, os.Chmod(name, 0o600))
+
+		require.NoError(t, WriteFile(name, []byte("new")))
+
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(content))
+
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("replaces target of the symlink and keeps the link", func(t *testing.T) {
+		require.NoError(t, os.Mkdir(filepath.Join(td, "real"), 0o755))
+		target := filepath.Join(td, "real", "hosts")
+		require.NoError(t, os.WriteFile(target, []byte("old content"), 0o644))
+
+		link := filepath.Join(td, "linkhosts")
+		require.NoError(t, os.Symlink(filepath.Join("real", "hosts"), link))
+
+		require.NoError(t, WriteFile(link, []byte("new")))
+
+		info, err := os.Lstat(link)
+		require.NoError(t, err)
+		assert.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+
+		content, err := os.ReadFile(target)
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(content))
+	})
+
+	t.Run("leaves file intact if write fails", func(t *testing.T) {
+		name := filepath.Join(td, "intact")
+		require.NoError(t, os.WriteFile(name, []byte("original"), 0o644))
+
+		// Reading a directory fails in the middle of copying.
+		err := CopyFile(td, name)
+		require.Error(t, err)
+
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(content))
+
+		tmpFiles, err := filepath.Glob(filepath.Join(td, ".intact.tmp-*"))
+		require.NoError(t, err)
+		assert.Empty(t, tmpFiles)
+	})
+}
+
+// failingReader returns the data and then fails instead of io.EOF.
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("read failed")
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+func TestOverwrite(t *testing.T) {
+	td := t.TempDir()
+
+	t.Run("replaces content", func(t *testing.T) {
+		name := filepath.Join(td, "replaced")
+		require.NoError(t, os.WriteFile(name, []byte("original content"), 0o644))
+
+		require.NoError(t, overwrite(name, strings.NewReader("new")))
+
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(content))
+	})
+
+	t.Run("restores content if write fails midway", func(t *testing.T) {
+		name := filepath.Join(td, "restored")
+		require.NoError(t, os.WriteFile(name, []byte("original content"), 0o644))
+
+		err := overwrite(name, &failingReader{data: []byte("partial")})
+		require.Error(t, err)
+
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		assert.Equal(t, "original content", string(content))
+	})
+}
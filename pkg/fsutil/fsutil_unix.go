@@ -0,0 +1,37 @@
+//go:build unix
+
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// chown copies the ownership of the file described by info to the file.
+func chown(name string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	err := os.Chown(name, int(stat.Uid), int(stat.Gid))
+	// Only privileged users can change the ownership, but it doesn't matter
+	// if the owner of the file is the current user anyway.
+	if errors.Is(err, os.ErrPermission) && int(stat.Uid) == os.Getuid() {
+		return nil
+	}
+
+	return err
+}
+
+// syncDir flushes the directory entry, so the rename survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// abpRule is a network rule of Adblock Plus and AdGuard filter lists
+// that blocks or allows a whole domain, i.e. ||ads.example.com^$third-party.
+type abpRule struct {
+	domain string
+	// exception is set for @@ rules allowing the domain.
+	exception bool
+	// important is set for rules that can't be overridden by exceptions.
+	important bool
+	// badfilter is set for rules disabling the same rules without it.
+	badfilter bool
+}
+
+// cosmeticSeparators separate domains from selectors of cosmetic rules,
+// like example.com##.banner.
+var cosmeticSeparators = []string{"##", "#@#", "#?#", "#@?#", "#$#", "#@$#", "#%#", "#@%#"}
+
+// abpModifiers are modifiers that don't restrict the rule to some requests,
+// so the rule still blocks the whole domain.
+var abpModifiers = map[string]struct{}{
+	"third-party":  {},
+	"~third-party": {},
+	"3p":           {},
+	"~3p":          {},
+	"first-party":  {},
+	"~first-party": {},
+	"1p":           {},
+	"~1p":          {},
+	"all":          {},
+	"document":     {},
+	"doc":          {},
+	"popup":        {},
+	"match-case":   {},
+}
+
+// isABPRule checks if the line is a rule of Adblock Plus or AdGuard syntax
+// rather than a domain or a hosts entry.
+func isABPRule(line string) bool {
+	if strings.HasPrefix(line, "|") || strings.HasPrefix(line, "@@") {
+		return true
+	}
+
+	return isCosmeticRule(line)
+}
+
+// isCosmeticRule checks if the line is a rule hiding elements of web pages.
+// The separator must go before any whitespace, so comments of hosts entries
+// aren't taken for cosmetic rules.
+func isCosmeticRule(line string) bool {
+	prefix := line
+	if i := strings.IndexFunc(line, unicode.IsSpace); i != -1 {
+		prefix = line[:i]
+	}
+
+	for _, separator := range cosmeticSeparators {
+		if strings.Contains(prefix, separator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseAdblockLine parses a rule of Adblock Plus and AdGuard syntax.
+// Plain domains are blocked as well.
+func parseAdblockLine(line string) Entry {
+	if !isABPRule(line) {
+		return parseHostsLine(line)
+	}
+
+	rule, ok := parseABPRule(line)
+	if !ok {
+		return Entry{Skip: SkipUnsupportedRule}
+	}
+
+	return Entry{
+		Domains:   []string{rule.domain},
+		Exception: rule.exception,
+		Important: rule.important,
+		Badfilter: rule.badfilter,
+	}
+}
+
+// sniffAdblockLine checks if the line is specific to Adblock Plus lists.
+// Comments and headers of them don't count, as they're often added
+// to lists of other formats.
+func sniffAdblockLine(line string) bool {
+	return isABPRule(line)
+}
+
+// parseABPRule parses the rule blocking or allowing the whole domain.
+// It returns false for cosmetic rules, rules matching paths or URL patterns
+// and rules with modifiers restricting them to some requests.
+func parseABPRule(line string) (abpRule, bool) {
+	if isCosmeticRule(line) {
+		return abpRule{}, false
+	}
+
+	var rule abpRule
+
+	if strings.HasPrefix(line, "@@") {
+		rule.exception = true
+		line = strings.TrimPrefix(line, "@@")
+	}
+
+	if !strings.HasPrefix(line, "||") {
+		return abpRule{}, false
+	}
+	line = strings.TrimPrefix(line, "||")
+
+	pattern, modifiers, _ := strings.Cut(line, "$")
+
+	if modifiers != "" {
+		for _, modifier := range strings.Split(modifiers, ",") {
+			switch modifier = strings.TrimSpace(modifier); modifier {
+			case "important":
+				rule.important = true
+			case "badfilter":
+				rule.badfilter = true
+			default:
+				if _, ok := abpModifiers[modifier]; !ok {
+					return abpRule{}, false
+				}
+			}
+		}
+	}
+
+	// The separator ^ may be followed by the end of address anchor |.
+	pattern = strings.TrimSuffix(pattern, "|")
+	pattern = strings.TrimSuffix(pattern, "^")
+
+	if pattern == "" || strings.ContainsAny(pattern, "/*^|:?=&") {
+		return abpRule{}, false
+	}
+
+	rule.domain = pattern
+
+	return rule, true
+}
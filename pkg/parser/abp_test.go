@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseABPRule(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected abpRule
+		ok       bool
+	}{
+		{rule: "||ads.example.com^", expected: abpRule{domain: "ads.example.com"}, ok: true},
+		{rule: "||ads.example.com^$third-party", expected: abpRule{domain: "ads.example.com"}, ok: true},
+		{rule: "||example.com^|", expected: abpRule{domain: "example.com"}, ok: true},
+		{rule: "||example.com", expected: abpRule{domain: "example.com"}, ok: true},
+		{rule: "@@||cdn.example.com^", expected: abpRule{domain: "cdn.example.com", exception: true}, ok: true},
+		{rule: "||ads.example.com^$important", expected: abpRule{domain: "ads.example.com", important: true}, ok: true},
+		{rule: "||ads.example.com^$badfilter", expected: abpRule{domain: "ads.example.com", badfilter: true}, ok: true},
+		{rule: "||example.com/ads/*", ok: false},
+		{rule: "||ads.*.example.com^", ok: false},
+		{rule: "||example.com^$script", ok: false},
+		{rule: "||example.com^$domain=example.org", ok: false},
+		{rule: "|https://example.com/banner.png", ok: false},
+		{rule: "example.com##.banner", ok: false},
+		{rule: "example.com#@#.banner", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, ok := parseABPRule(tt.rule)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, rule)
+		})
+	}
+}
+
+func TestIsABPRule(t *testing.T) {
+	assert.True(t, isABPRule("||example.com^"))
+	assert.True(t, isABPRule("@@||example.com^"))
+	assert.True(t, isABPRule("example.com##.banner"))
+	assert.False(t, isABPRule("example.com"))
+	assert.False(t, isABPRule("0.0.0.0 example.com # ## section"))
+}
+
+func TestParseAdblock(t *testing.T) {
+	content := "[Adblock Plus 2.0]\n" +
+		"! Title: test\n" +
+		"||ads.example.com^\n" +
+		"||tracker.example.com^$third-party\n" +
+		"||cdn.example.com^\n" +
+		"@@||cdn.example.com^\n" +
+		"||metrics.example.com^$important\n" +
+		"@@||metrics.example.com^\n" +
+		"||old.example.com^\n" +
+		"||old.example.com^$badfilter\n" +
+		"example.com##.banner\n" +
+		"||example.com/ads/*\n" +
+		"plain.example.com\n"
+
+	parser, ok := Lookup(FormatAdblock)
+	require.True(t, ok)
+
+	result := parser.Parse(content)
+
+	assert.Equal(t, []string{"ads.example.com", "tracker.example.com", "cdn.example.com", "metrics.example.com", "plain.example.com"}, result.Domains)
+	assert.Equal(t, []string{"cdn.example.com", "metrics.example.com"}, result.Exceptions)
+	assert.Equal(t, []string{"metrics.example.com"}, result.Important)
+	assert.Equal(t, map[SkipReason]int{SkipUnsupportedRule: 2}, result.Skipped)
+}
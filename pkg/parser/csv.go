@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions are options of lists in CSV format.
+type CSVOptions struct {
+	// Comma is the field delimiter. It's a comma by default.
+	Comma rune
+	// Column is the 1-based number or the name of the column with domains
+	// or URLs. The name is looked up in the header. It's 1 by default.
+	Column string
+	// Header is set if the first row is the header rather than an entry.
+	// It's always set if the column is a name.
+	Header bool
+}
+
+// CSVParser is a parser of CSV and TSV lists, like PhishTank and URLhaus
+// feeds. Rows may contain domains or full URLs, and their hostnames are used.
+// Lines starting with # are comments.
+type CSVParser struct {
+	name string
+	// index is the 0-based index of the column, or -1 if it's looked up
+	// in the header by its name.
+	index   int
+	options CSVOptions
+}
+
+// NewCSVParser returns a parser of CSV lists with the name of the format.
+func NewCSVParser(name string, options CSVOptions) (*CSVParser, error) {
+	if options.Comma == 0 {
+		options.Comma = ','
+	}
+
+	if options.Column == "" {
+		options.Column = "1"
+	}
+
+	index := -1
+	if number, err := strconv.Atoi(options.Column); err == nil {
+		if number < 1 {
+			return nil, fmt.Errorf("invalid column %d: columns are numbered from 1", number)
+		}
+		index = number - 1
+	} else {
+		options.Header = true
+	}
+
+	return &CSVParser{
+		name:    name,
+		index:   index,
+		options: options,
+	}, nil
+}
+
+// Name returns the name of the format.
+func (p *CSVParser) Name() string {
+	return p.name
+}
+
+// Parse parses rows of the list.
+func (p *CSVParser) Parse(content string) Result {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = p.options.Comma
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	domains := newDomainSet()
+	skipped := make(map[SkipReason]int)
+
+	index := p.index
+	header := p.options.Header
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			skipped[SkipUnsupportedLine]++
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		if header {
+			header = false
+			if index == -1 {
+				index = columnIndex(record, p.options.Column)
+			}
+
+			continue
+		}
+
+		if index == -1 || index >= len(record) {
+			skipped[SkipMissingColumn]++
+			continue
+		}
+
+		host, reason := hostFromURL(record[index])
+		if reason == "" {
+			reason = checkDomain(host)
+		}
+		if reason != "" {
+			skipped[reason]++
+			continue
+		}
+
+		domains.add(host)
+	}
+
+	return Result{
+		Domains: domains.list(),
+		Skipped: skipped,
+	}
+}
+
+// columnIndex returns the index of the column with the name in the header,
+// or -1 if there is no such column.
+func columnIndex(header []string, name string) int {
+	for i, column := range header {
+		if strings.EqualFold(strings.TrimSpace(column), name) {
+			return i
+		}
+	}
+
+	return -1
+}
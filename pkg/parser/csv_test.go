@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  CSVOptions
+		content  string
+		expected []string
+		skipped  map[SkipReason]int
+	}{
+		{
+			name:    "first column",
+			options: CSVOptions{},
+			content: "ads.example.com,2024-10-17\n" +
+				"https://login.example.com/account,2024-10-17\n",
+			expected: []string{"ads.example.com", "login.example.com"},
+			skipped:  map[SkipReason]int{},
+		},
+		{
+			name:    "column number with comments",
+			options: CSVOptions{Column: "3"},
+			content: "# URLhaus feed\n" +
+				"# id,dateadded,url,url_status\n" +
+				"\"1\",\"2024-10-17 10:15:00\",\"http://malware.example.net/bins/x86\",\"online\"\n" +
+				"\"2\",\"2024-10-17 10:16:00\",\"http://93.184.216.34/bins/mirai.arm\",\"online\"\n" +
+				"\"3\",\"2024-10-17 10:17:00\"\n",
+			expected: []string{"malware.example.net"},
+			skipped:  map[SkipReason]int{SkipIPHost: 1, SkipMissingColumn: 1},
+		},
+		{
+			name:    "column name",
+			options: CSVOptions{Column: "URL"},
+			content: "phish_id,url,phish_detail_url\n" +
+				"1,https://login.example.com/account,https://phishtank.org/1\n" +
+				"2,http://localhost/login,https://phishtank.org/2\n",
+			expected: []string{"login.example.com"},
+			skipped:  map[SkipReason]int{SkipReservedDomain: 1},
+		},
+		{
+			name:    "header",
+			options: CSVOptions{Column: "2", Header: true},
+			content: "id,domain\n" +
+				"1,ads.example.com\n",
+			expected: []string{"ads.example.com"},
+			skipped:  map[SkipReason]int{},
+		},
+		{
+			name:    "tsv",
+			options: CSVOptions{Comma: '\t', Column: "2"},
+			content: "1\tads.example.com\n" +
+				"2\ttracker.example.com\n",
+			expected: []string{"ads.example.com", "tracker.example.com"},
+			skipped:  map[SkipReason]int{},
+		},
+		{
+			name:    "unknown column name",
+			options: CSVOptions{Column: "host"},
+			content: "id,url\n" +
+				"1,https://login.example.com/account\n",
+			skipped: map[SkipReason]int{SkipMissingColumn: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewCSVParser(FormatCSV, tt.options)
+			require.NoError(t, err)
+
+			result := parser.Parse(tt.content)
+
+			assert.Equal(t, tt.expected, result.Domains)
+			assert.Equal(t, tt.skipped, result.Skipped)
+		})
+	}
+
+	t.Run("invalid column", func(t *testing.T) {
+		_, err := NewCSVParser(FormatCSV, CSVOptions{Column: "0"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+)
+
+// first part (before +): subdomain pattern.
+// second part (after +): top level domain (TLD) pattern.
+var validDomainRegexp = regexp.MustCompile(`^([a-z0-9_-]{0,63}\.)+[a-z0-9][a-z0-9-]{0,61}[a-z0-9]$`)
+
+// reservedDomains must never be blocked.
+// Some lists (i.e StevenBlack's) contain these as they are supposed to be used as HOST.
+var reservedDomains = []string{
+	"localhost",
+	"localhost.localdomain",
+	"local",
+	"broadcasthost",
+	"ip6-localhost",
+	"ip6-loopback",
+	"lo0 localhost",
+	"ip6-localnet",
+	"ip6-mcastprefix",
+	"ip6-allnodes",
+	"ip6-allrouters",
+	"ip6-allhosts",
+	"0.0.0.0",
+}
+
+// IsReservedDomain checks if the domain must never be blocked, like localhost.
+func IsReservedDomain(domain string) bool {
+	return slices.Contains(reservedDomains, domain)
+}
+
+// IsValidDomain checks if the lowercased domain is valid.
+func IsValidDomain(domain string) bool {
+	return validDomainRegexp.MatchString(domain)
+}
+
+// IsSinkAddress checks if the IP address is one of the addresses used
+// to block domains. Some lists use 0 as a short form of 0.0.0.0.
+func IsSinkAddress(ip string) bool {
+	switch ip {
+	case "0", "0.0.0.0", "127.0.0.1", "::", "::1":
+		return true
+	}
+
+	return false
+}
+
+// checkDomain returns the reason why the domain can't be blocked,
+// or an empty string if it can.
+func checkDomain(domain string) SkipReason {
+	if IsReservedDomain(domain) {
+		return SkipReservedDomain
+	}
+
+	if !IsValidDomain(domain) {
+		return SkipInvalidDomain
+	}
+
+	return ""
+}
@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"net"
+	"strings"
+)
+
+// parseDnsmasqLine parses dnsmasq options blocking domains:
+// address=/example.com/0.0.0.0, address=/example.com/, local=/example.com/
+// and server=/example.com/. Several domains may be separated by slashes.
+func parseDnsmasqLine(line string) Entry {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok || !strings.HasPrefix(value, "/") {
+		return Entry{Skip: SkipUnsupportedLine}
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+	if len(parts) < 2 {
+		return Entry{Skip: SkipUnsupportedLine}
+	}
+
+	domains, address := parts[:len(parts)-1], parts[len(parts)-1]
+
+	switch key {
+	case "address":
+		// # is a short form of 0.0.0.0 and ::.
+		if address != "" && address != "#" && !IsSinkAddress(address) {
+			return Entry{Skip: SkipRealIP}
+		}
+	case "local", "server":
+		// Servers with an upstream forward queries instead of blocking.
+		if address != "" {
+			return Entry{Skip: SkipUnsupportedRule}
+		}
+	default:
+		return Entry{Skip: SkipUnsupportedLine}
+	}
+
+	return Entry{Domains: domains}
+}
+
+func sniffDnsmasqLine(line string) bool {
+	return strings.HasPrefix(line, "address=/") || strings.HasPrefix(line, "local=/") || strings.HasPrefix(line, "server=/")
+}
+
+// unboundBlockingZones are types of unbound local zones that block domains.
+var unboundBlockingZones = map[string]struct{}{
+	"always_nxdomain": {},
+	"always_refuse":   {},
+	"always_null":     {},
+	"always_deny":     {},
+	"deny":            {},
+	"inform_deny":     {},
+	"refuse":          {},
+	"static":          {},
+}
+
+// parseUnboundLine parses unbound local zones and data blocking domains:
+// local-zone: "example.com" always_nxdomain and
+// local-data: "example.com A 0.0.0.0".
+func parseUnboundLine(line string) Entry {
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return Entry{Skip: SkipUnsupportedLine}
+	}
+
+	switch key {
+	case "server":
+		return Entry{}
+	case "local-zone":
+		fields := strings.Fields(value)
+		if len(fields) != 2 {
+			return Entry{Skip: SkipUnsupportedLine}
+		}
+
+		if _, ok := unboundBlockingZones[fields[1]]; !ok {
+			return Entry{Skip: SkipUnsupportedRule}
+		}
+
+		return Entry{Domains: []string{unquoteDomain(fields[0])}}
+	case "local-data":
+		fields := strings.Fields(strings.Trim(strings.TrimSpace(value), `"'`))
+		if len(fields) < 3 {
+			return Entry{Skip: SkipUnsupportedLine}
+		}
+
+		if !IsSinkAddress(fields[len(fields)-1]) {
+			return Entry{Skip: SkipRealIP}
+		}
+
+		return Entry{Domains: []string{unquoteDomain(fields[0])}}
+	}
+
+	return Entry{Skip: SkipUnsupportedLine}
+}
+
+func sniffUnboundLine(line string) bool {
+	return strings.HasPrefix(line, "local-zone:") || strings.HasPrefix(line, "local-data:")
+}
+
+// parseRPZLine parses records of response policy zones:
+// example.com CNAME . blocks the domain, example.com CNAME rpz-passthru.
+// allows it. Other records of the zone, like SOA and NS, are skipped.
+func parseRPZLine(line string) Entry {
+	if strings.HasPrefix(line, ";") {
+		return Entry{}
+	}
+
+	line, _, _ = strings.Cut(line, ";")
+
+	fields := strings.Fields(line)
+
+	recordType := rpzRecordType(fields)
+	if recordType == "" || strings.HasPrefix(line, "$") {
+		return Entry{Skip: SkipUnsupportedLine}
+	}
+
+	domain := strings.TrimPrefix(unquoteDomain(fields[0]), "*.")
+	data := fields[len(fields)-1]
+
+	switch recordType {
+	case "cname":
+		switch data {
+		case ".", "*.", "rpz-drop.":
+			return Entry{Domains: []string{domain}}
+		case "rpz-passthru.":
+			return Entry{Domains: []string{domain}, Exception: true}
+		}
+	case "a", "aaaa":
+		if !IsSinkAddress(data) {
+			return Entry{Skip: SkipRealIP}
+		}
+
+		return Entry{Domains: []string{domain}}
+	}
+
+	return Entry{Skip: SkipUnsupportedRule}
+}
+
+func sniffRPZLine(line string) bool {
+	return strings.HasPrefix(line, "$ttl") || strings.HasPrefix(line, "$origin") ||
+		rpzRecordType(strings.Fields(line)) != ""
+}
+
+// rpzRecordType returns the lowercased type of the zone record,
+// skipping its TTL and class. It returns an empty string if the fields
+// aren't a record.
+func rpzRecordType(fields []string) string {
+	if len(fields) < 3 {
+		return ""
+	}
+
+	for _, field := range fields[1 : len(fields)-1] {
+		switch field {
+		case "in":
+			continue
+		case "cname", "a", "aaaa", "soa", "ns", "txt":
+			return field
+		}
+
+		if strings.Trim(field, "0123456789") != "" {
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// parseWildcardLine parses domains that match their subdomains as well:
+// *.example.com, .example.com or example.com. The hosts file can't block
+// subdomains, so blocklists block only the domain itself, while whitelists
+// allow its subdomains too.
+func parseWildcardLine(line string) Entry {
+	line, _, _ = strings.Cut(line, "#")
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return Entry{}
+	}
+
+	if strings.ContainsAny(line, " \t") || net.ParseIP(line) != nil {
+		return Entry{Skip: SkipUnsupportedLine}
+	}
+
+	domain := strings.TrimPrefix(line, "*")
+	domain = strings.TrimPrefix(domain, ".")
+
+	return Entry{Domains: []string{domain}, Wildcard: domain != line}
+}
+
+func sniffWildcardLine(line string) bool {
+	return strings.HasPrefix(line, "*.") || strings.HasPrefix(line, ".")
+}
+
+// unquoteDomain returns the domain without quotes and the trailing dot
+// of fully qualified names.
+func unquoteDomain(domain string) string {
+	domain = strings.Trim(domain, `"'`)
+	return strings.TrimSuffix(domain, ".")
+}
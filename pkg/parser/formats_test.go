@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		content  string
+		expected []string
+		skipped  map[SkipReason]int
+	}{
+		{
+			name:   "dnsmasq",
+			format: FormatDnsmasq,
+			content: "# dnsmasq list\n" +
+				"address=/ads.example.com/0.0.0.0\n" +
+				"address=/tracker.example.com/\n" +
+				"address=/a.example.org/b.example.org/#\n" +
+				"local=/metrics.example.com/\n" +
+				"server=/cdn.example.com/\n" +
+				"address=/example.net/93.184.216.34\n" +
+				"server=/corp.example.com/10.0.0.1\n",
+			expected: []string{"ads.example.com", "tracker.example.com", "a.example.org", "b.example.org", "metrics.example.com", "cdn.example.com"},
+			skipped:  map[SkipReason]int{SkipRealIP: 1, SkipUnsupportedRule: 1},
+		},
+		{
+			name:   "unbound",
+			format: FormatUnbound,
+			content: "server:\n" +
+				"local-zone: \"ads.example.com\" always_nxdomain\n" +
+				"local-zone: \"tracker.example.com.\" static\n" +
+				"local-data: \"metrics.example.com A 0.0.0.0\"\n" +
+				"local-data: \"example.net A 93.184.216.34\"\n" +
+				"local-zone: \"example.org\" transparent\n",
+			expected: []string{"ads.example.com", "tracker.example.com", "metrics.example.com"},
+			skipped:  map[SkipReason]int{SkipRealIP: 1, SkipUnsupportedRule: 1},
+		},
+		{
+			name:   "rpz",
+			format: FormatRPZ,
+			content: "$TTL 300\n" +
+				"@ IN SOA localhost. root.localhost. (1 3600 600 86400 300)\n" +
+				"  IN NS localhost.\n" +
+				"; blocked domains\n" +
+				"ads.example.com CNAME .\n" +
+				"*.ads.example.com CNAME .\n" +
+				"tracker.example.com 300 IN CNAME *.\n" +
+				"metrics.example.com A 0.0.0.0\n" +
+				"example.net A 93.184.216.34\n" +
+				"cdn.example.com CNAME rpz-passthru.\n" +
+				"example.org CNAME example.net.\n",
+			expected: []string{"ads.example.com", "tracker.example.com", "metrics.example.com"},
+			skipped:  map[SkipReason]int{SkipRealIP: 1, SkipUnsupportedLine: 1, SkipUnsupportedRule: 3},
+		},
+		{
+			name:   "wildcard",
+			format: FormatWildcard,
+			content: "# wildcard list\n" +
+				"*.ads.example.com\n" +
+				".tracker.example.com\n" +
+				"metrics.example.com # comment\n" +
+				"0.0.0.0 example.net\n",
+			expected: []string{"ads.example.com", "tracker.example.com", "metrics.example.com"},
+			skipped:  map[SkipReason]int{SkipUnsupportedLine: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, ok := Lookup(tt.format)
+			require.True(t, ok)
+
+			result := parser.Parse(tt.content)
+
+			assert.ElementsMatch(t, tt.expected, result.Domains)
+			assert.Equal(t, tt.skipped, result.Skipped)
+			assert.Equal(t, tt.format, Detect(tt.content).Name())
+		})
+	}
+
+	t.Run("wildcards", func(t *testing.T) {
+		parser, ok := Lookup(FormatWildcard)
+		require.True(t, ok)
+
+		result := parser.Parse("*.ads.example.com\n.tracker.example.com\nmetrics.example.com\n")
+
+		assert.Equal(t, []string{"ads.example.com", "tracker.example.com"}, result.Wildcards)
+	})
+
+	t.Run("wildcards in plain domain lists", func(t *testing.T) {
+		for _, format := range []string{FormatHosts, FormatDomains} {
+			parser, ok := Lookup(format)
+			require.True(t, ok)
+
+			result := parser.Parse("example.com\n*.ads.example.com\n.tracker.example.com\n")
+
+			assert.Equal(t, []string{"example.com", "ads.example.com", "tracker.example.com"}, result.Domains, format)
+			assert.Equal(t, []string{"ads.example.com", "tracker.example.com"}, result.Wildcards, format)
+		}
+	})
+
+	t.Run("rpz exceptions", func(t *testing.T) {
+		parser, ok := Lookup(FormatRPZ)
+		require.True(t, ok)
+
+		result := parser.Parse("cdn.example.com CNAME rpz-passthru.\n")
+
+		assert.Equal(t, []string{"cdn.example.com"}, result.Exceptions)
+	})
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{content: "0.0.0.0 ads.example.com\nexample.com\n", expected: FormatHosts},
+		{content: "[Adblock Plus 2.0]\n||ads.example.com^\nexample.com\n", expected: FormatAdblock},
+		{content: "address=/ads.example.com/0.0.0.0\n", expected: FormatDnsmasq},
+		{content: "server:\nlocal-zone: \"ads.example.com\" always_nxdomain\n", expected: FormatUnbound},
+		{content: "$TTL 300\nads.example.com CNAME .\n", expected: FormatRPZ},
+		{content: "*.ads.example.com\nexample.com\n", expected: FormatWildcard},
+		{content: "", expected: FormatHosts},
+		{content: "; generated list\n0.0.0.0 ads.example.com\n0.0.0.0 tracker.example.com\n", expected: FormatHosts},
+		{content: "! title\n[adblock]\nads.example.com\ntracker.example.com\n", expected: FormatHosts},
+		{
+			content:  "*.ads.example.com\n0.0.0.0 a.example.com\n0.0.0.0 b.example.com\n0.0.0.0 c.example.com\n0.0.0.0 d.example.com\n",
+			expected: FormatHosts,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Detect(tt.content).Name())
+		})
+	}
+}
+
+func TestParseHosts(t *testing.T) {
+	content := "# hosts list\n" +
+		"0.0.0.0 ads.example.com tracker.example.com # comment\n" +
+		"127.0.0.1 localhost\n" +
+		"metrics.example.com\n" +
+		"93.184.216.34 example.net\n" +
+		"0.0.0.0 bad_domain!\n" +
+		"not a line\n"
+
+	parser, ok := Lookup(FormatHosts)
+	require.True(t, ok)
+
+	result := parser.Parse(content)
+
+	assert.Equal(t, []string{"ads.example.com", "tracker.example.com", "metrics.example.com"}, result.Domains)
+	assert.Equal(t, map[SkipReason]int{
+		SkipRealIP:          1,
+		SkipReservedDomain:  1,
+		SkipInvalidDomain:   1,
+		SkipUnsupportedLine: 1,
+	}, result.Skipped)
+}
+
+func TestParseDomains(t *testing.T) {
+	parser, ok := Lookup(FormatDomains)
+	require.True(t, ok)
+
+	result := parser.Parse("ads.example.com\n# comment\ntracker.example.com # comment\n0.0.0.0 example.net\n")
+
+	assert.Equal(t, []string{"ads.example.com", "tracker.example.com"}, result.Domains)
+	assert.Equal(t, map[SkipReason]int{SkipUnsupportedLine: 1}, result.Skipped)
+}
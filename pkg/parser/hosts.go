@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"net"
+	"strings"
+)
+
+// parseHostsLine parses a domain or an entry in the hosts file format.
+// Every hostname of the entry is used, if it points to a sink address.
+// A single domain may be a wildcard, like *.example.com.
+func parseHostsLine(line string) Entry {
+	line, _, _ = strings.Cut(line, "#")
+
+	parts := strings.Fields(line)
+	switch {
+	case len(parts) == 0:
+		return Entry{}
+	case len(parts) == 1:
+		return domainEntry(parts[0])
+	case IsSinkAddress(parts[0]):
+		return Entry{Domains: parts[1:]}
+	case net.ParseIP(parts[0]) != nil:
+		return Entry{Skip: SkipRealIP}
+	}
+
+	return Entry{Skip: SkipUnsupportedLine}
+}
+
+// parseDomainsLine parses a line with a single domain, which may be
+// a wildcard, like *.example.com.
+func parseDomainsLine(line string) Entry {
+	line, _, _ = strings.Cut(line, "#")
+
+	parts := strings.Fields(line)
+	switch len(parts) {
+	case 0:
+		return Entry{}
+	case 1:
+		return domainEntry(parts[0])
+	}
+
+	return Entry{Skip: SkipUnsupportedLine}
+}
+
+// domainEntry returns the entry of the domain. The domain starting with *.
+// or . is a wildcard the same way as in the wildcard format, so lists
+// of plain domains may have a few wildcards mixed in.
+func domainEntry(domain string) Entry {
+	for _, prefix := range []string{"*.", "."} {
+		if trimmed, ok := strings.CutPrefix(domain, prefix); ok {
+			return Entry{Domains: []string{trimmed}, Wildcard: true}
+		}
+	}
+
+	return Entry{Domains: []string{domain}}
+}
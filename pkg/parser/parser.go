@@ -0,0 +1,222 @@
+// Package parser parses blocklists and whitelists of domains in different
+// formats: hosts files, Adblock Plus rules, dnsmasq options, threat feeds
+// of URLs and so on.
+package parser
+
+import (
+	"strings"
+)
+
+// SkipReason is a reason why an entry of the list was skipped.
+type SkipReason string
+
+const (
+	// SkipRealIP is a line mapping domains to an address that isn't a sink,
+	// which is a real mapping rather than blocking.
+	SkipRealIP SkipReason = "real ip"
+	// SkipUnsupportedLine is a line that can't be parsed.
+	SkipUnsupportedLine SkipReason = "unsupported line"
+	// SkipUnsupportedRule is a rule that doesn't block the whole domain,
+	// i.e. a cosmetic rule or a rule matching paths.
+	SkipUnsupportedRule SkipReason = "unsupported rule"
+	// SkipReservedDomain is a domain like localhost that must not be blocked.
+	SkipReservedDomain SkipReason = "reserved domain"
+	// SkipInvalidDomain is a hostname that isn't a valid domain.
+	SkipInvalidDomain SkipReason = "invalid domain"
+	// SkipIPHost is a URL with an IP address instead of the hostname.
+	SkipIPHost SkipReason = "ip host"
+	// SkipMissingColumn is a row of the CSV list without the column with domains.
+	SkipMissingColumn SkipReason = "missing column"
+	// SkipInvalidRegex is a line of the regex list that isn't a valid expression.
+	SkipInvalidRegex SkipReason = "invalid regex"
+)
+
+// Parser parses content of the list in some format.
+type Parser interface {
+	// Name returns the name of the format, which is used in the config.
+	Name() string
+	// Parse returns domains of the list and diagnostics of parsing.
+	Parse(content string) Result
+}
+
+// Sniffer is implemented by parsers that can recognize lines specific
+// to their format. It's used to detect the format of the list.
+type Sniffer interface {
+	// Sniff checks if the trimmed lowercased line is specific to the format.
+	Sniff(line string) bool
+}
+
+// Result is a parsed list.
+type Result struct {
+	// Domains are domains to block in order of their first appearance.
+	Domains []string
+	// Exceptions are domains that must not be blocked.
+	Exceptions []string
+	// Important are domains of Domains that can't be allowed by exceptions.
+	Important []string
+	// Wildcards are domains of Domains that match their subdomains as well.
+	// The hosts file can't block subdomains, so they're used by whitelists.
+	Wildcards []string
+	// Patterns are valid regular expressions matching domains.
+	Patterns []string
+	// Skipped is the number of skipped entries by the reason.
+	Skipped map[SkipReason]int
+}
+
+// Entry is a parsed line of the list.
+type Entry struct {
+	Domains []string
+	// Exception is set if the domains must not be blocked.
+	Exception bool
+	// Important is set if the domains can't be allowed by exceptions.
+	Important bool
+	// Badfilter is set if the entry disables the same entries without it.
+	Badfilter bool
+	// Wildcard is set if the domains match their subdomains as well.
+	Wildcard bool
+	// Skip is the reason why the line is skipped.
+	Skip SkipReason
+}
+
+// LineParser is a parser of the format where every line is parsed
+// on its own. Empty lines and comments starting with #, ! or [ are skipped,
+// and domains are validated, so parse functions only extract them.
+type LineParser struct {
+	name  string
+	parse func(line string) Entry
+	sniff func(line string) bool
+}
+
+// NewLineParser returns a parser of the format with the name. The parse
+// function gets trimmed lowercased lines and returns an empty entry for lines
+// without rules. The sniff function may be nil if lines of the format
+// can't be recognized.
+func NewLineParser(name string, parse func(line string) Entry, sniff func(line string) bool) *LineParser {
+	return &LineParser{
+		name:  name,
+		parse: parse,
+		sniff: sniff,
+	}
+}
+
+// Name returns the name of the format.
+func (p *LineParser) Name() string {
+	return p.name
+}
+
+// Sniff checks if the line is specific to the format.
+func (p *LineParser) Sniff(line string) bool {
+	return p.sniff != nil && p.sniff(line)
+}
+
+// Parse parses content of the list line by line.
+func (p *LineParser) Parse(content string) Result {
+	var (
+		domains    = newDomainSet()
+		exceptions = newDomainSet()
+		important  = newDomainSet()
+		wildcards  = newDomainSet()
+		badfilters []Entry
+	)
+
+	skipped := make(map[SkipReason]int)
+
+	for _, rawLine := range strings.Split(content, "\n") {
+		line := strings.ToLower(strings.TrimSpace(rawLine))
+		if isComment(line) {
+			continue
+		}
+
+		entry := p.parse(line)
+		if entry.Skip != "" {
+			skipped[entry.Skip]++
+			continue
+		}
+
+		for _, domain := range entry.Domains {
+			if reason := checkDomain(domain); reason != "" {
+				skipped[reason]++
+				continue
+			}
+
+			switch {
+			case entry.Badfilter:
+				badfilters = append(badfilters, Entry{Domains: []string{domain}, Exception: entry.Exception})
+			case entry.Exception:
+				exceptions.add(domain)
+			default:
+				domains.add(domain)
+				if entry.Important {
+					important.add(domain)
+				}
+				if entry.Wildcard {
+					wildcards.add(domain)
+				}
+			}
+		}
+	}
+
+	// Rules with badfilter modifier disable the same rules of the list.
+	for _, entry := range badfilters {
+		domain := entry.Domains[0]
+		if entry.Exception {
+			exceptions.remove(domain)
+			continue
+		}
+
+		domains.remove(domain)
+		important.remove(domain)
+		wildcards.remove(domain)
+	}
+
+	return Result{
+		Domains:    domains.list(),
+		Exceptions: exceptions.list(),
+		Important:  important.list(),
+		Wildcards:  wildcards.list(),
+		Skipped:    skipped,
+	}
+}
+
+// isComment checks if the line is empty or a comment of any format.
+// [ starts headers of Adblock Plus lists.
+func isComment(line string) bool {
+	return line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") || strings.HasPrefix(line, "[")
+}
+
+// domainSet is a set of domains keeping order of their addition.
+type domainSet struct {
+	order   []string
+	present map[string]bool
+}
+
+func newDomainSet() *domainSet {
+	return &domainSet{present: make(map[string]bool)}
+}
+
+func (s *domainSet) add(domain string) {
+	if _, ok := s.present[domain]; ok {
+		s.present[domain] = true
+		return
+	}
+
+	s.order = append(s.order, domain)
+	s.present[domain] = true
+}
+
+func (s *domainSet) remove(domain string) {
+	if _, ok := s.present[domain]; ok {
+		s.present[domain] = false
+	}
+}
+
+func (s *domainSet) list() []string {
+	var domains []string
+	for _, domain := range s.order {
+		if s.present[domain] {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains
+}
@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RegexParser is a parser of lists of regular expressions matching domains,
+// one per line, like regex lists of Pi-hole. Lines aren't lowercased,
+// as it changes the meaning of expressions, but domains are matched
+// in lowercase. Lines starting with # are comments.
+type RegexParser struct{}
+
+// Name returns the name of the format.
+func (p *RegexParser) Name() string {
+	return FormatRegex
+}
+
+// Parse returns valid expressions of the list.
+func (p *RegexParser) Parse(content string) Result {
+	var patterns []string
+	skipped := make(map[SkipReason]int)
+
+	for _, rawLine := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if _, err := regexp.Compile(line); err != nil {
+			skipped[SkipInvalidRegex]++
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return Result{
+		Patterns: patterns,
+		Skipped:  skipped,
+	}
+}
@@ -0,0 +1,25 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegex(t *testing.T) {
+	content := "# regex list\n" +
+		"^ad[0-9]+\\.example\\.net$\n" +
+		"  .*\\.doubleclick\\..*  \n" +
+		"^\\D+\\.tracker\\.com$\n" +
+		"(unclosed\n"
+
+	parser, ok := Lookup(FormatRegex)
+	require.True(t, ok)
+
+	result := parser.Parse(content)
+
+	assert.Equal(t, []string{`^ad[0-9]+\.example\.net$`, `.*\.doubleclick\..*`, `^\D+\.tracker\.com$`}, result.Patterns)
+	assert.Empty(t, result.Domains)
+	assert.Equal(t, map[SkipReason]int{SkipInvalidRegex: 1}, result.Skipped)
+}
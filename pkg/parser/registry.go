@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"strings"
+	"sync"
+)
+
+// Names of the built-in formats.
+const (
+	// FormatHosts is a list of domains or entries in the hosts file format.
+	FormatHosts = "hosts"
+	// FormatDomains is a list of domains, one per line.
+	FormatDomains = "domains"
+	// FormatAdblock is a list of Adblock Plus and AdGuard rules.
+	FormatAdblock = "adblock"
+	// FormatDnsmasq is a list of dnsmasq options, like address=/example.com/.
+	FormatDnsmasq = "dnsmasq"
+	// FormatUnbound is a list of unbound local zones and data.
+	FormatUnbound = "unbound"
+	// FormatRPZ is a response policy zone of BIND.
+	FormatRPZ = "rpz"
+	// FormatWildcard is a list of domains blocking their subdomains, like *.example.com.
+	FormatWildcard = "wildcard"
+	// FormatURLs is a threat feed of full URLs, like https://example.com/login.php.
+	FormatURLs = "urls"
+	// FormatCSV is a threat feed of comma-separated values with domains or URLs
+	// in the first column.
+	FormatCSV = "csv"
+	// FormatTSV is a threat feed of tab-separated values with domains or URLs
+	// in the first column.
+	FormatTSV = "tsv"
+	// FormatRegex is a list of regular expressions matching domains.
+	FormatRegex = "regex"
+)
+
+// sniffLines is the number of lines used to detect the format of the list.
+const sniffLines = 200
+
+// detectShare is the inverse of the smallest share of checked lines
+// that have to be sniffed by the parser to detect its format.
+const detectShare = 4
+
+var (
+	mu sync.RWMutex
+	// parsers are registered parsers in order of their registration.
+	parsers []Parser
+)
+
+func init() {
+	Register(NewLineParser(FormatHosts, parseHostsLine, nil))
+	Register(NewLineParser(FormatDomains, parseDomainsLine, nil))
+	Register(NewLineParser(FormatAdblock, parseAdblockLine, sniffAdblockLine))
+	Register(NewLineParser(FormatDnsmasq, parseDnsmasqLine, sniffDnsmasqLine))
+	Register(NewLineParser(FormatUnbound, parseUnboundLine, sniffUnboundLine))
+	Register(NewLineParser(FormatRPZ, parseRPZLine, sniffRPZLine))
+	Register(NewLineParser(FormatWildcard, parseWildcardLine, sniffWildcardLine))
+	Register(NewLineParser(FormatURLs, parseURLLine, sniffURLLine))
+	Register(mustCSVParser(FormatCSV, CSVOptions{Comma: ','}))
+	Register(mustCSVParser(FormatTSV, CSVOptions{Comma: '\t'}))
+	Register(&RegexParser{})
+}
+
+func mustCSVParser(name string, options CSVOptions) *CSVParser {
+	parser, err := NewCSVParser(name, options)
+	if err != nil {
+		panic(err)
+	}
+
+	return parser
+}
+
+// Register registers the parser, so lists can use its format.
+// The parser replaces the registered one with the same name.
+func Register(parser Parser) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i, registered := range parsers {
+		if registered.Name() == parser.Name() {
+			parsers[i] = parser
+			return
+		}
+	}
+
+	parsers = append(parsers, parser)
+}
+
+// Lookup returns the registered parser of the format.
+func Lookup(name string) (Parser, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, parser := range parsers {
+		if parser.Name() == name {
+			return parser, true
+		}
+	}
+
+	return nil, false
+}
+
+// Names returns names of all the registered formats.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(parsers))
+	for _, parser := range parsers {
+		names = append(names, parser.Name())
+	}
+
+	return names
+}
+
+// Detect returns the parser of the list format detected by its first lines.
+// Every line votes for the first parser sniffing it, comments and headers
+// don't vote. The format is used only if its lines are at least a quarter
+// of the checked ones. Lines of plain domains are valid in most
+// of the formats, so the hosts format is used otherwise.
+func Detect(content string) Parser {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	votes := make([]int, len(parsers))
+
+	checked := 0
+	for _, rawLine := range strings.Split(content, "\n") {
+		if checked == sniffLines {
+			break
+		}
+
+		line := strings.ToLower(strings.TrimSpace(rawLine))
+		if isComment(line) || strings.HasPrefix(line, ";") {
+			continue
+		}
+		checked++
+
+		for i, parser := range parsers {
+			if sniffer, ok := parser.(Sniffer); ok && sniffer.Sniff(line) {
+				votes[i]++
+				break
+			}
+		}
+	}
+
+	detected := -1
+	for i := range parsers {
+		if votes[i]*detectShare >= checked && votes[i] > 0 && (detected == -1 || votes[i] > votes[detected]) {
+			detected = i
+		}
+	}
+
+	if detected == -1 {
+		for _, parser := range parsers {
+			if parser.Name() == FormatHosts {
+				return parser
+			}
+		}
+	}
+
+	return parsers[detected]
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	names := Names()
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		parsers = parsers[:len(names)]
+	})
+
+	assert.Equal(t, []string{FormatHosts, FormatDomains, FormatAdblock, FormatDnsmasq, FormatUnbound, FormatRPZ, FormatWildcard, FormatURLs, FormatCSV, FormatTSV, FormatRegex}, names)
+
+	_, ok := Lookup("custom")
+	assert.False(t, ok)
+
+	parseCustomLine := func(line string) Entry {
+		domain, ok := strings.CutPrefix(line, "block ")
+		if !ok {
+			return Entry{Skip: SkipUnsupportedLine}
+		}
+
+		return Entry{Domains: []string{domain}}
+	}
+	sniffCustomLine := func(line string) bool {
+		_, ok := strings.CutPrefix(line, "block ")
+		return ok
+	}
+
+	Register(NewLineParser("custom", parseCustomLine, sniffCustomLine))
+
+	parser, ok := Lookup("custom")
+	require.True(t, ok)
+
+	content := "block ads.example.com\nblock tracker.example.com\n"
+	assert.Equal(t, []string{"ads.example.com", "tracker.example.com"}, parser.Parse(content).Domains)
+	assert.Equal(t, "custom", Detect(content).Name())
+
+	t.Run("replaces the parser with the same name", func(t *testing.T) {
+		Register(NewLineParser("custom", parseDomainsLine, nil))
+
+		parser, ok := Lookup("custom")
+		require.True(t, ok)
+
+		assert.Len(t, Names(), len(names)+1)
+		assert.Equal(t, []string{"example.com"}, parser.Parse("example.com\n").Domains)
+	})
+}
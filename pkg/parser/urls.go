@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// parseURLLine parses a line of threat feeds listing full URLs,
+// like URLhaus and OpenPhish: https://example.com/login.php.
+func parseURLLine(line string) Entry {
+	host, reason := hostFromURL(line)
+	if reason != "" {
+		return Entry{Skip: reason}
+	}
+
+	return Entry{Domains: []string{host}}
+}
+
+func sniffURLLine(line string) bool {
+	return strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://")
+}
+
+// hostFromURL returns the lowercased hostname of the URL. Values without
+// a scheme, like example.com/login.php, are treated as URLs as well.
+// URLs with IP address hosts are skipped, as there is no domain to block.
+func hostFromURL(value string) (string, SkipReason) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ContainsAny(value, " \t") {
+		return "", SkipUnsupportedLine
+	}
+
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Hostname() == "" {
+		return "", SkipUnsupportedLine
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if net.ParseIP(host) != nil {
+		return "", SkipIPHost
+	}
+
+	return host, ""
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostFromURL(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+		reason   SkipReason
+	}{
+		{value: "https://login.example.com/account?id=1", expected: "login.example.com"},
+		{value: "http://Example.COM:8080/", expected: "example.com"},
+		{value: "example.com/login.php", expected: "example.com"},
+		{value: "https://example.com./", expected: "example.com"},
+		{value: "http://93.184.216.34/bins/mirai.arm", reason: SkipIPHost},
+		{value: "http://[2606:2800:220:1::1]/", reason: SkipIPHost},
+		{value: "", reason: SkipUnsupportedLine},
+		{value: "not a url", reason: SkipUnsupportedLine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			host, reason := hostFromURL(tt.value)
+
+			assert.Equal(t, tt.expected, host)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestParseURLs(t *testing.T) {
+	content := "# URLhaus feed\n" +
+		"https://login.example.com/account\n" +
+		"http://login.example.com/verify#step2\n" +
+		"http://malware.example.net/bins/x86\n" +
+		"http://93.184.216.34/bins/mirai.arm\n"
+
+	parser, ok := Lookup(FormatURLs)
+	require.True(t, ok)
+
+	result := parser.Parse(content)
+
+	assert.Equal(t, []string{"login.example.com", "malware.example.net"}, result.Domains)
+	assert.Equal(t, map[SkipReason]int{SkipIPHost: 1}, result.Skipped)
+	assert.Equal(t, FormatURLs, Detect(content).Name())
+}